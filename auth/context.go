@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"context"
+
+	"petstore-api/models"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID uint          `json:"user_id"`
+	Roles  []models.Role `json:"roles"`
+}
+
+func (p *Principal) HasRole(role models.Role) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Principal) IsAdmin() bool {
+	return p.HasRole(models.RoleAdmin)
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the caller stored by the auth middleware, or
+// false for anonymous requests.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"petstore-api/models"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func TestIssueVerify(t *testing.T) {
+	tokens := NewTokenManager(testSecret, time.Hour)
+	user := &models.User{ID: 42, Roles: []models.UserRole{{Role: models.RoleSeller}, {Role: models.RoleBuyer}}}
+
+	token, expiresAt, err := tokens.Issue(user)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if d := time.Until(expiresAt); d <= 59*time.Minute || d > time.Hour {
+		t.Errorf("token expires in %v, want an hour", d)
+	}
+
+	principal, err := tokens.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	want := &Principal{UserID: 42, Roles: []models.Role{models.RoleSeller, models.RoleBuyer}}
+	if !reflect.DeepEqual(principal, want) {
+		t.Errorf("Verify() = %+v, want %+v", principal, want)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	tokens := NewTokenManager(testSecret, time.Hour)
+	user := &models.User{ID: 42, Roles: []models.UserRole{{Role: models.RoleBuyer}}}
+	valid, _, err := tokens.Issue(user)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	parts := strings.Split(valid, ".")
+
+	expired, _, err := NewTokenManager(testSecret, -time.Minute).Issue(user)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	otherSecret, _, err := NewTokenManager([]byte("fedcba9876543210fedcba9876543210"), time.Hour).Issue(user)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	// resign builds a correctly signed token around the given header and
+	// claims, so only the checks after the signature can reject it.
+	resign := func(header tokenHeader, claims tokenClaims) string {
+		h, err := encodeSegment(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := encodeSegment(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h + "." + c + "." + tokens.sign(h+"."+c)
+	}
+	future := time.Now().Add(time.Hour).Unix()
+
+	admin, err := encodeSegment(tokenClaims{Subject: "42", Roles: []models.Role{models.RoleAdmin}, ExpiresAt: future})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"expired", expired, ErrExpiredToken},
+		{"signed with another secret", otherSecret, ErrInvalidToken},
+		{"tampered signature", parts[0] + "." + parts[1] + "." + strings.Repeat("A", len(parts[2])), ErrInvalidToken},
+		{"tampered claims", parts[0] + "." + admin + "." + parts[2], ErrInvalidToken},
+		{"no signature", parts[0] + "." + parts[1] + ".", ErrInvalidToken},
+		{"alg none", resign(tokenHeader{Alg: "none", Typ: "JWT"}, tokenClaims{Subject: "42", ExpiresAt: future}), ErrInvalidToken},
+		{"other algorithm", resign(tokenHeader{Alg: "HS512", Typ: "JWT"}, tokenClaims{Subject: "42", ExpiresAt: future}), ErrInvalidToken},
+		{"subject not a number", resign(tokenHeader{Alg: "HS256", Typ: "JWT"}, tokenClaims{Subject: "ann", ExpiresAt: future}), ErrInvalidToken},
+		{"subject zero", resign(tokenHeader{Alg: "HS256", Typ: "JWT"}, tokenClaims{Subject: "0", ExpiresAt: future}), ErrInvalidToken},
+		{"no expiry", resign(tokenHeader{Alg: "HS256", Typ: "JWT"}, tokenClaims{Subject: "42"}), ErrExpiredToken},
+		{"two segments", parts[0] + "." + parts[1], ErrInvalidToken},
+		{"four segments", valid + ".x", ErrInvalidToken},
+		{"empty", "", ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := tokens.Verify(tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() = %+v, %v, want %v", principal, err, tt.wantErr)
+			}
+		})
+	}
+}
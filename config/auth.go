@@ -0,0 +1,49 @@
+package config
+
+import (
+	"log"
+	"strings"
+	"time"
+)
+
+// minSecretLength is the shortest signing secret accepted, matching the
+// output size of the HMAC-SHA256 it keys.
+const minSecretLength = 32
+
+// placeholderSecrets are values that once shipped in example env files and
+// must never sign anything in a real deployment.
+var placeholderSecrets = []string{"change-me", "change-me-too", "changeme", "secret"}
+
+type AuthConfig struct {
+	JWTSecret  string
+	TokenTTL   time.Duration
+	AdminEmail string
+}
+
+func LoadAuthConfig() *AuthConfig {
+	config := &AuthConfig{
+		JWTSecret:  getEnv("JWT_SECRET", ""),
+		TokenTTL:   getEnvAsDuration("JWT_TTL", 24*time.Hour),
+		AdminEmail: getEnv("ADMIN_EMAIL", ""),
+	}
+
+	checkSecret("JWT_SECRET", config.JWTSecret)
+
+	return config
+}
+
+// checkSecret refuses to start with a missing, placeholder or short signing
+// secret.
+func checkSecret(name, value string) {
+	if value == "" {
+		log.Fatalf("%s must be set", name)
+	}
+	for _, placeholder := range placeholderSecrets {
+		if strings.EqualFold(value, placeholder) {
+			log.Fatalf("%s is set to a placeholder value; generate one with `openssl rand -hex 32`", name)
+		}
+	}
+	if len(value) < minSecretLength {
+		log.Fatalf("%s must be at least %d bytes long", name, minSecretLength)
+	}
+}
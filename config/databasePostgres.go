@@ -5,8 +5,6 @@ import (
 	"log"
 	"os"
 
-	"petstore-api/models"
-
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -51,7 +49,7 @@ func InitDB() *gorm.DB {
 	}
 	fmt.Println("Database connection successful")
 	fmt.Println("Running database migrations...")
-	err = db.AutoMigrate(&models.Seller{}, &models.Pet{})
+	err = migrate(db)
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
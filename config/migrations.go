@@ -0,0 +1,342 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"petstore-api/models"
+
+	"gorm.io/gorm"
+)
+
+// migration is a one-off data change that AutoMigrate cannot express. Each
+// migration runs once, inside a transaction, before AutoMigrate brings the
+// tables in line with the models, so it has to cope with both a fresh
+// database and one created by an older version of the service.
+type migration struct {
+	ID string
+	Up func(tx *gorm.DB) error
+}
+
+type schemaMigration struct {
+	ID        string    `gorm:"primaryKey;size:255"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+var migrations = []migration{
+	{ID: "0001_merge_sellers_and_buyers_into_users", Up: mergeSellersAndBuyers},
+	{ID: "0002_store_money_as_minor_units", Up: storeMoneyAsMinorUnits},
+	{ID: "0003_add_pet_search_vector", Up: addPetSearchVector},
+	{ID: "0004_seed_pet_taxonomy", Up: seedPetTaxonomy},
+	{ID: "0005_replace_pet_available_with_status", Up: replacePetAvailableWithStatus},
+	{ID: "0006_scope_user_email_uniqueness_to_active_users", Up: scopeUserEmailUniqueness},
+}
+
+// migrate applies the pending migrations and then brings every table in
+// line with the models.
+func migrate(db *gorm.DB) error {
+	if err := runMigrations(db); err != nil {
+		return err
+	}
+	return db.AutoMigrate(&models.User{}, &models.UserRole{}, &models.Species{}, &models.SpeciesAlias{}, &models.Breed{}, &models.BreedAlias{}, &models.PedigreeEntry{}, &models.Pet{}, &models.PetStatusHistory{}, &models.PetPhoto{}, &models.HealthRecord{}, &models.Order{}, &models.OrderItem{}, &models.OrderStatusHistory{}, &models.Payment{}, &models.PaymentEvent{})
+}
+
+func runMigrations(db *gorm.DB) error {
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		var applied int64
+		if err := db.Model(&schemaMigration{}).Where("id = ?", m.ID).Count(&applied).Error; err != nil {
+			return err
+		}
+		if applied > 0 {
+			continue
+		}
+
+		fmt.Printf("Applying migration %s...\n", m.ID)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{ID: m.ID, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %s: %w", m.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// mergeSellersAndBuyers moves the separate sellers and buyers tables into a
+// single users table with role grants. Seller IDs are kept so existing pets
+// still point at the right owner; a buyer whose email already belongs to a
+// seller is merged into that account.
+func mergeSellersAndBuyers(tx *gorm.DB) error {
+	hasSellers := tx.Migrator().HasTable("sellers")
+	hasBuyers := tx.Migrator().HasTable("buyers")
+	if !hasSellers && !hasBuyers {
+		return nil
+	}
+
+	if err := tx.AutoMigrate(&models.User{}, &models.UserRole{}); err != nil {
+		return err
+	}
+
+	var statements []string
+	if hasSellers {
+		statements = append(statements,
+			`INSERT INTO users (id, name, email, phone, address, created_at, updated_at)
+			 SELECT id, name, email, phone, address, created_at, updated_at FROM sellers
+			 ON CONFLICT DO NOTHING`,
+			`INSERT INTO user_roles (user_id, role, created_at)
+			 SELECT u.id, 'seller', NOW() FROM sellers s JOIN users u ON u.email = s.email
+			 ON CONFLICT DO NOTHING`,
+			`SELECT setval(pg_get_serial_sequence('users', 'id'), COALESCE((SELECT MAX(id) FROM users), 0) + 1, false)`,
+		)
+	}
+	if hasBuyers {
+		statements = append(statements,
+			`INSERT INTO users (name, email, phone, address, created_at, updated_at)
+			 SELECT name, email, phone, address, created_at, updated_at FROM buyers
+			 ON CONFLICT (email) WHERE deleted_at IS NULL DO NOTHING`,
+			`INSERT INTO user_roles (user_id, role, created_at)
+			 SELECT u.id, 'buyer', NOW() FROM buyers b JOIN users u ON u.email = b.email
+			 ON CONFLICT DO NOTHING`,
+		)
+	}
+	statements = append(statements,
+		`ALTER TABLE IF EXISTS pets DROP CONSTRAINT IF EXISTS fk_pets_seller`,
+		`ALTER TABLE IF EXISTS pets DROP CONSTRAINT IF EXISTS fk_sellers_pets`,
+		`DROP TABLE IF EXISTS buyers`,
+		`DROP TABLE IF EXISTS sellers`,
+	)
+
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// storeMoneyAsMinorUnits replaces the decimal(10,2) price columns with an
+// integer amount in minor units plus a currency code. Legacy prices carry no
+// currency, so they are taken to be in the default currency; legacy payments
+// already record theirs and are scaled by it. decimal(10,2) values times 100
+// are whole numbers, so nothing is rounded away.
+func storeMoneyAsMinorUnits(tx *gorm.DB) error {
+	columns := []struct{ table, column string }{
+		{"pets", "price"},
+		{"orders", "total"},
+		{"order_items", "price"},
+	}
+	for _, c := range columns {
+		if !tx.Migrator().HasColumn(c.table, c.column) {
+			continue
+		}
+
+		statements := []string{
+			fmt.Sprintf(`ALTER TABLE %[1]s ADD COLUMN %[2]s_amount bigint NOT NULL DEFAULT 0,
+			 ADD COLUMN %[2]s_currency varchar(3) NOT NULL DEFAULT '%[3]s'`, c.table, c.column, models.DefaultCurrency),
+			fmt.Sprintf(`UPDATE %[1]s SET %[2]s_amount = ROUND(COALESCE(%[2]s, 0) * %[3]d)`,
+				c.table, c.column, minorUnitScale(models.DefaultCurrency)),
+			fmt.Sprintf(`ALTER TABLE %s DROP COLUMN %s`, c.table, c.column),
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	var amountType string
+	err := tx.Raw(`SELECT data_type FROM information_schema.columns
+		WHERE table_schema = CURRENT_SCHEMA() AND table_name = 'payments' AND column_name = 'amount'`).
+		Scan(&amountType).Error
+	if err != nil {
+		return err
+	}
+	if amountType != "numeric" {
+		return nil
+	}
+
+	var currencies []string
+	if err := tx.Raw(`SELECT DISTINCT currency FROM payments`).Scan(&currencies).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec(`ALTER TABLE payments ADD COLUMN amount_minor bigint NOT NULL DEFAULT 0`).Error; err != nil {
+		return err
+	}
+	for _, currency := range currencies {
+		err := tx.Exec(`UPDATE payments SET amount_minor = ROUND(amount * ?) WHERE currency = ?`,
+			minorUnitScale(currency), currency).Error
+		if err != nil {
+			return err
+		}
+	}
+	statements := []string{
+		`ALTER TABLE payments DROP COLUMN amount`,
+		`ALTER TABLE payments RENAME COLUMN amount_minor TO amount`,
+	}
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addPetSearchVector adds the full-text search column behind GET
+// /pets/search. It is a generated column, so Postgres keeps it current on
+// every insert and update; names, species and breeds are weighted above
+// descriptions. The models do not map the column, so AutoMigrate leaves it
+// alone.
+func addPetSearchVector(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&models.Pet{}); err != nil {
+		return err
+	}
+
+	statements := []string{
+		`ALTER TABLE pets ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+			setweight(to_tsvector('english', COALESCE(name, '')), 'A') ||
+			setweight(to_tsvector('english', COALESCE(species, '')), 'B') ||
+			setweight(to_tsvector('english', COALESCE(breed, '')), 'B') ||
+			setweight(to_tsvector('english', COALESCE(description, '')), 'C')
+		) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_pets_search_vector ON pets USING GIN (search_vector)`,
+	}
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// seedPetTaxonomy creates the species and breed reference tables with the
+// default taxonomy, then rewrites the species and breeds of existing pets
+// that match a name or alias to the canonical name. Pets that match nothing
+// keep their values; they are only checked again when they are edited.
+func seedPetTaxonomy(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&models.Pet{}, &models.Species{}, &models.SpeciesAlias{}, &models.Breed{}, &models.BreedAlias{}); err != nil {
+		return err
+	}
+
+	for _, seed := range defaultTaxonomy {
+		species := models.Species{Name: seed.Name}
+		for _, alias := range seed.Aliases {
+			species.Aliases = append(species.Aliases, models.SpeciesAlias{Name: alias})
+		}
+		if err := tx.Create(&species).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&models.Pet{}).Where("lower(species) IN ?", lowerNames(seed.Name, seed.Aliases)).
+			Update("species", species.Name).Error
+		if err != nil {
+			return err
+		}
+
+		for _, breedSeed := range seed.Breeds {
+			breed := models.Breed{SpeciesID: species.ID, Name: breedSeed.Name}
+			for _, alias := range breedSeed.Aliases {
+				breed.Aliases = append(breed.Aliases, models.BreedAlias{SpeciesID: species.ID, Name: alias})
+			}
+			if err := tx.Create(&breed).Error; err != nil {
+				return err
+			}
+			err := tx.Model(&models.Pet{}).Where("species = ? AND lower(breed) IN ?", species.Name, lowerNames(breed.Name, breedSeed.Aliases)).
+				Update("breed", breed.Name).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// replacePetAvailableWithStatus maps the old available flag onto listing
+// statuses. Available pets are listed. An unavailable pet is reserved while
+// an open order holds it, sold once an order for it completed, and
+// withdrawn otherwise. Every pet gets a first history entry dated at its
+// last update.
+func replacePetAvailableWithStatus(tx *gorm.DB) error {
+	if !tx.Migrator().HasColumn("pets", "available") {
+		return nil
+	}
+	if err := tx.AutoMigrate(&models.Pet{}, &models.PetStatusHistory{}); err != nil {
+		return err
+	}
+
+	unavailable := fmt.Sprintf(`UPDATE pets SET status = '%s' WHERE NOT available`, models.PetStatusWithdrawn)
+	if tx.Migrator().HasTable("orders") {
+		unavailable = fmt.Sprintf(`UPDATE pets SET status = CASE
+			WHEN EXISTS (SELECT 1 FROM order_items JOIN orders ON orders.id = order_items.order_id
+				WHERE order_items.pet_id = pets.id AND orders.status IN ('%s', '%s', '%s')) THEN '%s'
+			WHEN EXISTS (SELECT 1 FROM order_items JOIN orders ON orders.id = order_items.order_id
+				WHERE order_items.pet_id = pets.id AND orders.status = '%s') THEN '%s'
+			ELSE '%s' END
+			WHERE NOT available`,
+			models.OrderStatusPending, models.OrderStatusPaid, models.OrderStatusHandedOver, models.PetStatusReserved,
+			models.OrderStatusCompleted, models.PetStatusSold, models.PetStatusWithdrawn)
+	}
+
+	statements := []string{
+		fmt.Sprintf(`UPDATE pets SET status = '%s' WHERE available`, models.PetStatusListed),
+		unavailable,
+		`UPDATE pets SET status_changed_at = updated_at`,
+		`INSERT INTO pet_status_histories (pet_id, from_status, to_status, note, created_at)
+			SELECT id, '', status, 'migrated from the available flag', updated_at FROM pets`,
+		`ALTER TABLE pets DROP COLUMN available`,
+	}
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scopeUserEmailUniqueness drops the table-wide unique constraint on user
+// emails, so a soft-deleted user's address can be registered again.
+// AutoMigrate then adds the unique index over users that are not deleted.
+// Older versions of GORM named the constraint differently, hence the
+// several names.
+func scopeUserEmailUniqueness(tx *gorm.DB) error {
+	if !tx.Migrator().HasTable("users") {
+		return nil
+	}
+
+	statements := []string{
+		`ALTER TABLE users DROP CONSTRAINT IF EXISTS uni_users_email`,
+		`ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key`,
+		`DROP INDEX IF EXISTS idx_users_email`,
+	}
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func lowerNames(name string, aliases []string) []string {
+	names := []string{strings.ToLower(name)}
+	for _, alias := range aliases {
+		names = append(names, strings.ToLower(alias))
+	}
+	return names
+}
+
+func minorUnitScale(currency string) int64 {
+	return models.FromMajorUnits(1, currency).Amount
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"petstore-api/models"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB connects to the database named by TEST_DATABASE_DSN and gives
+// the test an empty schema of its own, dropped again at the end.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	// search_path is per connection, so every query has to use the same one.
+	sqlDB.SetMaxOpenConns(1)
+
+	schema := fmt.Sprintf("migration_test_%d", time.Now().UnixNano())
+	if err := db.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec("SET search_path TO public")
+		db.Exec("DROP SCHEMA " + schema + " CASCADE")
+		sqlDB.Close()
+	})
+	if err := db.Exec("SET search_path TO " + schema).Error; err != nil {
+		t.Fatalf("set search_path: %v", err)
+	}
+
+	return db
+}
+
+// TestMigrateFromSeparateSellersAndBuyers upgrades the schema from before
+// users and roles, when sellers and buyers had tables of their own and pets
+// had a decimal price and an available flag.
+func TestMigrateFromSeparateSellersAndBuyers(t *testing.T) {
+	db := openTestDB(t)
+
+	legacy := []string{
+		`CREATE TABLE sellers (
+			id bigserial PRIMARY KEY,
+			name varchar(255) NOT NULL,
+			email varchar(255) NOT NULL UNIQUE,
+			phone varchar(20),
+			address varchar(500),
+			created_at timestamptz,
+			updated_at timestamptz)`,
+		`CREATE TABLE buyers (
+			id bigserial PRIMARY KEY,
+			name varchar(255) NOT NULL,
+			email varchar(255) NOT NULL UNIQUE,
+			phone varchar(20),
+			address varchar(500),
+			created_at timestamptz,
+			updated_at timestamptz)`,
+		`CREATE TABLE pets (
+			id bigserial PRIMARY KEY,
+			name varchar(255) NOT NULL,
+			species varchar(100) NOT NULL,
+			breed varchar(100),
+			age bigint CHECK (age >= 0),
+			price decimal(10,2) CHECK (price >= 0),
+			description text,
+			available boolean DEFAULT true,
+			seller_id bigint NOT NULL,
+			created_at timestamptz,
+			updated_at timestamptz,
+			CONSTRAINT fk_sellers_pets FOREIGN KEY (seller_id) REFERENCES sellers (id))`,
+		`INSERT INTO sellers (id, name, email, created_at, updated_at) VALUES
+			(1, 'Ann', 'ann@example.com', NOW(), NOW()),
+			(2, 'Bob', 'bob@example.com', NOW(), NOW())`,
+		`SELECT setval(pg_get_serial_sequence('sellers', 'id'), 2)`,
+		`INSERT INTO buyers (name, email, created_at, updated_at) VALUES
+			('Ann', 'ann@example.com', NOW(), NOW()),
+			('Cat', 'cat@example.com', NOW(), NOW())`,
+		`INSERT INTO pets (name, species, breed, age, price, description, available, seller_id, created_at, updated_at) VALUES
+			('Rex', 'dog', 'labrador', 3, 19.99, '', true, 2, NOW(), NOW()),
+			('Tom', 'cat', '', 1, 5.00, '', false, 1, NOW(), NOW())`,
+	}
+	for _, statement := range legacy {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("legacy schema: %v", err)
+		}
+	}
+
+	if err := migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	var users []models.User
+	if err := db.Preload("Roles").Order("email").Find(&users).Error; err != nil {
+		t.Fatalf("load users: %v", err)
+	}
+	want := map[string][]string{
+		"ann@example.com": {"buyer", "seller"},
+		"bob@example.com": {"seller"},
+		"cat@example.com": {"buyer"},
+	}
+	if len(users) != len(want) {
+		t.Fatalf("got %d users, want %d", len(users), len(want))
+	}
+	ids := make(map[string]uint)
+	for _, user := range users {
+		var roles []string
+		for _, role := range user.Roles {
+			roles = append(roles, string(role.Role))
+		}
+		sort.Strings(roles)
+		if fmt.Sprint(roles) != fmt.Sprint(want[user.Email]) {
+			t.Errorf("%s has roles %v, want %v", user.Email, roles, want[user.Email])
+		}
+		ids[user.Email] = user.ID
+	}
+	if ids["ann@example.com"] != 1 || ids["bob@example.com"] != 2 {
+		t.Errorf("seller IDs changed: %v", ids)
+	}
+
+	var pets []models.Pet
+	if err := db.Order("name").Find(&pets).Error; err != nil {
+		t.Fatalf("load pets: %v", err)
+	}
+	if len(pets) != 2 {
+		t.Fatalf("got %d pets, want 2", len(pets))
+	}
+	rex, tom := pets[0], pets[1]
+	if rex.SellerID != 2 || rex.Price.Amount != 1999 || rex.Price.Currency != models.DefaultCurrency || rex.Status != models.PetStatusListed || rex.Version != 1 {
+		t.Errorf("Rex migrated to %+v", rex)
+	}
+	if tom.SellerID != 1 || tom.Price.Amount != 500 || tom.Status != models.PetStatusWithdrawn {
+		t.Errorf("Tom migrated to %+v", tom)
+	}
+
+	// Emails stay unique among users that are not deleted.
+	if err := db.Create(&models.User{Name: "Ann again", Email: "ann@example.com"}).Error; err == nil {
+		t.Error("created a second active user with the same email")
+	}
+
+	if err := migrate(db); err != nil {
+		t.Fatalf("migrate again: %v", err)
+	}
+}
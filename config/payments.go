@@ -0,0 +1,26 @@
+package config
+
+import "log"
+
+type PaymentsConfig struct {
+	Provider      string
+	WebhookSecret string
+	WebhookURL    string
+	FakeFail      bool
+}
+
+func LoadPaymentsConfig() *PaymentsConfig {
+	config := &PaymentsConfig{
+		Provider:      getEnv("PAYMENTS_PROVIDER", "fake"),
+		WebhookSecret: getEnv("PAYMENTS_WEBHOOK_SECRET", ""),
+		WebhookURL:    getEnv("PAYMENTS_WEBHOOK_URL", "http://localhost:8080/webhooks/payments"),
+		FakeFail:      getEnvAsBool("PAYMENTS_FAKE_FAIL", false),
+	}
+
+	checkSecret("PAYMENTS_WEBHOOK_SECRET", config.WebhookSecret)
+	if config.Provider != "fake" {
+		log.Fatalf("unknown payments provider %q", config.Provider)
+	}
+
+	return config
+}
@@ -0,0 +1,17 @@
+package config
+
+import "time"
+
+// RetentionConfig controls how long deleted pets and users are kept before
+// they are purged for good. A PurgeInterval of zero turns the purge off.
+type RetentionConfig struct {
+	DeletedRetention time.Duration
+	PurgeInterval    time.Duration
+}
+
+func LoadRetentionConfig() *RetentionConfig {
+	return &RetentionConfig{
+		DeletedRetention: getEnvAsDuration("DELETED_RETENTION", 30*24*time.Hour),
+		PurgeInterval:    getEnvAsDuration("PURGE_INTERVAL", time.Hour),
+	}
+}
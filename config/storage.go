@@ -0,0 +1,17 @@
+package config
+
+type StorageConfig struct {
+	Dir            string
+	BaseURL        string
+	PhotoMaxBytes  int64
+	ThumbnailWidth int
+}
+
+func LoadStorageConfig() *StorageConfig {
+	return &StorageConfig{
+		Dir:            getEnv("STORAGE_DIR", "./uploads"),
+		BaseURL:        getEnv("STORAGE_BASE_URL", "http://localhost:8080/media"),
+		PhotoMaxBytes:  int64(getEnvAsInt("PHOTO_MAX_BYTES", 10<<20)),
+		ThumbnailWidth: getEnvAsInt("PHOTO_THUMBNAIL_WIDTH", 320),
+	}
+}
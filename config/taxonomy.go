@@ -0,0 +1,80 @@
+package config
+
+// taxonomySeed is the species and breed list a new database starts with.
+// Admins maintain it through the /species endpoints afterwards.
+type taxonomySeed struct {
+	Name    string
+	Aliases []string
+	Breeds  []breedSeed
+}
+
+type breedSeed struct {
+	Name    string
+	Aliases []string
+}
+
+var defaultTaxonomy = []taxonomySeed{
+	{Name: "Dog", Aliases: []string{"Dogs", "Doggo", "Puppy", "Canine"}, Breeds: []breedSeed{
+		{Name: "Beagle"},
+		{Name: "Border Collie"},
+		{Name: "Boxer"},
+		{Name: "Bulldog", Aliases: []string{"English Bulldog"}},
+		{Name: "Chihuahua"},
+		{Name: "Dachshund", Aliases: []string{"Sausage Dog"}},
+		{Name: "French Bulldog", Aliases: []string{"Frenchie"}},
+		{Name: "German Shepherd", Aliases: []string{"Alsatian", "GSD"}},
+		{Name: "Golden Retriever", Aliases: []string{"Golden"}},
+		{Name: "Labrador Retriever", Aliases: []string{"Labrador", "Lab"}},
+		{Name: "Poodle"},
+		{Name: "Pug"},
+		{Name: "Rottweiler", Aliases: []string{"Rottie"}},
+		{Name: "Shih Tzu"},
+		{Name: "Siberian Husky", Aliases: []string{"Husky"}},
+		{Name: "Yorkshire Terrier", Aliases: []string{"Yorkie"}},
+	}},
+	{Name: "Cat", Aliases: []string{"Cats", "Kitty", "Kitten", "Feline"}, Breeds: []breedSeed{
+		{Name: "Abyssinian"},
+		{Name: "Bengal"},
+		{Name: "British Shorthair"},
+		{Name: "Domestic Longhair", Aliases: []string{"DLH"}},
+		{Name: "Domestic Shorthair", Aliases: []string{"DSH"}},
+		{Name: "Maine Coon"},
+		{Name: "Persian"},
+		{Name: "Ragdoll"},
+		{Name: "Scottish Fold"},
+		{Name: "Siamese"},
+		{Name: "Sphynx"},
+	}},
+	{Name: "Bird", Aliases: []string{"Birds"}, Breeds: []breedSeed{
+		{Name: "African Grey Parrot", Aliases: []string{"African Grey"}},
+		{Name: "Budgerigar", Aliases: []string{"Budgie", "Parakeet"}},
+		{Name: "Canary"},
+		{Name: "Cockatiel"},
+		{Name: "Lovebird"},
+	}},
+	{Name: "Rabbit", Aliases: []string{"Rabbits", "Bunny"}, Breeds: []breedSeed{
+		{Name: "Flemish Giant"},
+		{Name: "Holland Lop"},
+		{Name: "Lionhead"},
+		{Name: "Mini Rex"},
+		{Name: "Netherland Dwarf"},
+	}},
+	{Name: "Hamster", Aliases: []string{"Hamsters"}, Breeds: []breedSeed{
+		{Name: "Campbell's Dwarf"},
+		{Name: "Roborovski", Aliases: []string{"Robo"}},
+		{Name: "Syrian", Aliases: []string{"Golden Hamster"}},
+		{Name: "Winter White"},
+	}},
+	{Name: "Guinea Pig", Aliases: []string{"Guinea Pigs", "Cavy"}, Breeds: []breedSeed{
+		{Name: "Abyssinian"},
+		{Name: "American"},
+		{Name: "Peruvian"},
+		{Name: "Teddy"},
+	}},
+	{Name: "Fish", Aliases: []string{"Fishes"}, Breeds: []breedSeed{
+		{Name: "Betta", Aliases: []string{"Siamese Fighting Fish"}},
+		{Name: "Goldfish"},
+		{Name: "Guppy"},
+		{Name: "Neon Tetra"},
+	}},
+}
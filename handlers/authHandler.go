@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"petstore-api/models"
+	"petstore-api/services"
+)
+
+type AuthHandler struct {
+	service services.AuthService
+}
+
+func NewAuthHandler(service services.AuthService) *AuthHandler {
+	return &AuthHandler{service: service}
+}
+
+// Register godoc
+// @Summary Register a new account
+// @Description Create a user with a password. Roles may be seller and/or buyer and default to buyer
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param user body models.RegisterRequest true "Registration data"
+// @Success 201 {object} Response{data=models.User}
+// @Failure 400 {object} Response
+// @Failure 409 {object} Response
+// @Router /auth/register [post]
+func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+	var req models.RegisterRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	user, err := h.service.Register(r.Context(), &req)
+	if err != nil {
+		if err.Error() == "email already registered" {
+			SendErrorResponse(w, http.StatusConflict, err.Error())
+			return
+		}
+		SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	SendCreatedResponse(w, user, "User registered successfully")
+}
+
+// Login godoc
+// @Summary Log in
+// @Description Exchange an email and password for a signed access token. Send it as "Authorization: Bearer <token>"
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body models.LoginRequest true "Login credentials"
+// @Success 200 {object} Response{data=models.LoginResponse}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 500 {object} Response
+// @Router /auth/login [post]
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req models.LoginRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	token, err := h.service.Login(&req)
+	if err != nil {
+		if err.Error() == "invalid email or password" {
+			SendErrorResponse(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		SendErrorResponse(w, http.StatusInternalServerError, "Failed to log in")
+		return
+	}
+
+	SendSuccessResponse(w, token, "")
+}
+
+// Me godoc
+// @Summary Get the current user
+// @Description Get the account the access token belongs to
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Success 200 {object} Response{data=models.User}
+// @Failure 401 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /auth/me [get]
+func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+	user, err := h.service.CurrentUser(r.Context())
+	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			SendErrorResponse(w, http.StatusUnauthorized, "authentication required")
+			return
+		}
+		if err.Error() == "user not found" {
+			SendErrorResponse(w, http.StatusNotFound, err.Error())
+			return
+		}
+		SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+
+	SendSuccessResponse(w, user, "")
+}
@@ -1 +1,148 @@
 package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"petstore-api/models"
+	"petstore-api/services"
+
+	"github.com/gorilla/mux"
+)
+
+type BucketHandler struct {
+	service services.BucketService
+}
+
+func NewBucketHandler(service services.BucketService) *BucketHandler {
+	return &BucketHandler{service: service}
+}
+
+// GetCart godoc
+// @Summary Get a buyer's cart
+// @Description Get the pets in a buyer's shopping cart. Pets that were deleted or are no longer listed are left out
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param id path int true "Buyer ID"
+// @Success 200 {object} Response{data=models.Cart}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /buyers/{id}/cart [get]
+func (h *BucketHandler) GetCart(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid buyer ID")
+		return
+	}
+
+	cart, err := h.service.GetCart(r.Context(), uint(id))
+	if err != nil {
+		sendCartError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, cart, "")
+}
+
+// AddToCart godoc
+// @Summary Add a pet to a buyer's cart
+// @Description Add a listed pet to the cart. Adding a pet that is already in the cart is a no-op
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param id path int true "Buyer ID"
+// @Param item body models.AddToCartRequest true "Pet to add"
+// @Success 200 {object} Response{data=models.Cart}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /buyers/{id}/cart [post]
+func (h *BucketHandler) AddToCart(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid buyer ID")
+		return
+	}
+
+	var req models.AddToCartRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || req.PetID == 0 {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	cart, err := h.service.AddToCart(r.Context(), uint(id), req.PetID)
+	if err != nil {
+		sendCartError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, cart, "Pet added to cart")
+}
+
+// RemoveFromCart godoc
+// @Summary Remove a pet from a buyer's cart
+// @Description Remove a pet from the cart
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param id path int true "Buyer ID"
+// @Param petId path int true "Pet ID"
+// @Success 200 {object} Response{data=models.Cart}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /buyers/{id}/cart/{petId} [delete]
+func (h *BucketHandler) RemoveFromCart(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid buyer ID")
+		return
+	}
+	petID, err := strconv.Atoi(vars["petId"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid pet ID")
+		return
+	}
+
+	cart, err := h.service.RemoveFromCart(r.Context(), uint(id), uint(petID))
+	if err != nil {
+		sendCartError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, cart, "Pet removed from cart")
+}
+
+func sendCartError(w http.ResponseWriter, err error) {
+	if errors.Is(err, services.ErrForbidden) {
+		SendErrorResponse(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	switch err.Error() {
+	case "buyer not found", "pet not found":
+		SendErrorResponse(w, http.StatusNotFound, err.Error())
+	case "pet is not available":
+		SendErrorResponse(w, http.StatusConflict, err.Error())
+	default:
+		SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+	}
+}
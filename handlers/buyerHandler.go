@@ -2,54 +2,127 @@ package handlers
 
 import (
 	"encoding/json"
-	"github.com/gorilla/mux"
+	"errors"
 	"net/http"
+	"strconv"
+
 	"petstore-api/models"
+	"petstore-api/repositories"
 	"petstore-api/services"
-	"strconv"
+
+	"github.com/gorilla/mux"
 )
 
 type BuyerHandler struct {
-	service services.SellerService
+	service services.UserService
 }
 
-func NewBuyerHandler(service services.SellerService) *BuyerHandler {
+func NewBuyerHandler(service services.UserService) *BuyerHandler {
 	return &BuyerHandler{service: service}
 }
 
-func (h *BuyerHandler) GetBuyers(w *http.ResponseWriter, r *http.Request) {
-	sellers, err := h.service.GetAllSellers()
+// GetBuyers godoc
+// @Summary Get all buyers
+// @Description Get list of all buyers. Only admins may do this
+// @Tags buyers
+// @Accept json
+// @Produce json
+// @Param include_deleted query bool false "Also include deleted buyers that have not been purged yet (admins only)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "Cursor from pagination.next_cursor of the previous page"
+// @Success 200 {object} Response{data=[]models.User}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /buyers [get]
+func (h *BuyerHandler) GetBuyers(w http.ResponseWriter, r *http.Request) {
+	includeDeleted := r.URL.Query().Get("include_deleted") == "true"
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	buyers, info, err := h.service.GetAll(r.Context(), false, includeDeleted, page)
 	if err != nil {
-		SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch sellers")
+		if errors.Is(err, services.ErrForbidden) {
+			SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, models.ErrInvalidCursor) {
+			SendErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch buyers")
 		return
 	}
 
-	SendSuccessResponse(w, sellers, "")
+	SendPageResponse(w, buyers, info, "")
 }
 
+// GetBuyer godoc
+// @Summary Get buyer by ID
+// @Description Get a single buyer by ID. Only the buyer themselves or an admin may do this
+// @Tags buyers
+// @Accept json
+// @Produce json
+// @Param id path int true "Buyer ID"
+// @Param include_deleted query bool false "Also find the buyer if they were deleted and have not been purged yet (admins only)"
+// @Success 200 {object} Response{data=models.User}
+// @Header 200 {string} ETag "Version of the buyer, for If-Match on PUT /buyers/{id}"
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /buyers/{id} [get]
 func (h *BuyerHandler) GetBuyer(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		SendErrorResponse(w, http.StatusBadRequest, "Invalid seller ID")
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid buyer ID")
 		return
 	}
 
-	seller, err := h.service.GetSellerByID(uint(id))
+	includeDeleted := r.URL.Query().Get("include_deleted") == "true"
+
+	buyer, err := h.service.GetByID(r.Context(), uint(id), false, includeDeleted)
 	if err != nil {
-		if err.Error() == "seller not found" {
+		if errors.Is(err, services.ErrForbidden) {
+			SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if err.Error() == "buyer not found" {
 			SendErrorResponse(w, http.StatusNotFound, err.Error())
 			return
 		}
-		SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch seller")
+		SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch buyer")
 		return
 	}
 
-	SendSuccessResponse(w, seller, "")
+	setETag(w, buyer.Version)
+	SendSuccessResponse(w, buyer, "")
 }
 
+// CreateBuyer godoc
+// @Summary Create a new buyer
+// @Description Create a new buyer account without a password (admin only). Users normally sign up through /auth/register
+// @Tags buyers
+// @Accept json
+// @Produce json
+// @Param buyer body models.CreateUserRequest true "Buyer creation data"
+// @Success 201 {object} Response{data=models.User}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Security BearerAuth
+// @Router /buyers [post]
 func (h *BuyerHandler) CreateBuyer(w http.ResponseWriter, r *http.Request) {
-	var req models.CreateSellerRequest
+	var req models.CreateUserRequest
 
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
@@ -57,58 +130,243 @@ func (h *BuyerHandler) CreateBuyer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	seller, err := h.service.CreateBuyer(&req)
+	buyer, err := h.service.Create(r.Context(), &req)
 	if err != nil {
 		SendErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	SendCreatedResponse(w, seller, "Seller created successfully")
+	SendCreatedResponse(w, buyer, "Buyer created successfully")
 }
 
+// UpdateBuyer godoc
+// @Summary Update buyer
+// @Description Update an existing buyer's information. Only the buyer themselves or an admin may do this. Send the ETag of the buyer as If-Match to make sure nobody else has changed it since; an update that loses against a concurrent change fails with 409 instead of overwriting it, as does an email another user already has
+// @Tags buyers
+// @Accept json
+// @Produce json
+// @Param id path int true "Buyer ID"
+// @Param If-Match header string false "ETag of the buyer as last read"
+// @Param buyer body models.UpdateUserRequest true "Buyer update data"
+// @Success 200 {object} Response{data=models.User}
+// @Header 200 {string} ETag "New version of the buyer"
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Failure 412 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /buyers/{id} [put]
 func (h *BuyerHandler) UpdateBuyer(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		SendErrorResponse(w, http.StatusBadRequest, "Invalid seller ID")
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid buyer ID")
 		return
 	}
 
-	var req models.UpdateSellerRequest
+	var req models.UpdateUserRequest
 	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
 		SendErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
 		return
 	}
 
-	seller, err := h.service.UpdateSeller(uint(id), &req)
+	buyer, err := h.service.Update(r.Context(), uint(id), &req, parseIfMatch(r))
 	if err != nil {
-		if err.Error() == "seller not found" {
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			sendVersionConflict(w, r, err)
+			return
+		}
+		if errors.Is(err, services.ErrForbidden) {
+			SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if err.Error() == "buyer not found" {
 			SendErrorResponse(w, http.StatusNotFound, err.Error())
 			return
 		}
+		if errors.Is(err, repositories.ErrEmailTaken) {
+			SendErrorResponse(w, http.StatusConflict, err.Error())
+			return
+		}
 		SendErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	SendSuccessResponse(w, seller, "Seller updated successfully")
+	setETag(w, buyer.Version)
+	SendSuccessResponse(w, buyer, "Buyer updated successfully")
 }
 
-func (h *BuyerHandler) DeleteSeller(w http.ResponseWriter, r *http.Request) {
+// DeleteBuyer godoc
+// @Summary Delete buyer
+// @Description Delete a buyer by ID. Deleted buyers can be restored by an admin until they are purged after the retention period
+// @Tags buyers
+// @Accept json
+// @Produce json
+// @Param id path int true "Buyer ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /buyers/{id} [delete]
+func (h *BuyerHandler) DeleteBuyer(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		SendErrorResponse(w, http.StatusBadRequest, "Invalid seller ID")
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid buyer ID")
 		return
 	}
 
-	err = h.service.DeleteBuyer(uint(id))
+	err = h.service.Delete(r.Context(), uint(id))
 	if err != nil {
-		if err.Error() == "seller not found" {
+		if errors.Is(err, services.ErrForbidden) {
+			SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if err.Error() == "buyer not found" {
 			SendErrorResponse(w, http.StatusNotFound, err.Error())
 			return
 		}
-		if err.Error() == "cannot delete seller with existing pets" {
+		SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	SendSuccessResponse(w, nil, "Buyer deleted successfully")
+}
+
+// RestoreBuyer godoc
+// @Summary Restore a deleted buyer
+// @Description Bring back a deleted buyer that has not been purged yet, with the roles they had. Fails if another user has taken the email since. Admin only
+// @Tags buyers
+// @Accept json
+// @Produce json
+// @Param id path int true "Buyer ID"
+// @Success 200 {object} Response{data=models.User}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /buyers/{id}/restore [post]
+func (h *BuyerHandler) RestoreBuyer(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid buyer ID")
+		return
+	}
+
+	buyer, err := h.service.Restore(r.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if err.Error() == "buyer not found" {
+			SendErrorResponse(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err.Error() == "user is not deleted" || errors.Is(err, repositories.ErrEmailTaken) {
+			SendErrorResponse(w, http.StatusConflict, err.Error())
+			return
+		}
+		SendErrorResponse(w, http.StatusInternalServerError, "Failed to restore buyer")
+		return
+	}
+
+	SendSuccessResponse(w, buyer, "Buyer restored successfully")
+}
+
+// GetBuyerVersions godoc
+// @Summary Get a buyer's version history
+// @Description Get every recorded create, update, delete and restore of a buyer's user record, oldest first, with who made it and the fields it changed. Changes made as a seller are included, since both share the record. Only the buyer themselves or an admin may do this
+// @Tags buyers
+// @Accept json
+// @Produce json
+// @Param id path int true "Buyer ID"
+// @Success 200 {object} Response{data=[]models.AuditEntry}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /buyers/{id}/versions [get]
+func (h *BuyerHandler) GetBuyerVersions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid buyer ID")
+		return
+	}
+
+	versions, err := h.service.GetVersions(r.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
+		SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	SendSuccessResponse(w, versions, "")
+}
+
+// RestoreBuyerVersion godoc
+// @Summary Restore a buyer version
+// @Description Put back the name, email, phone and address a buyer had in an earlier version. Roles are not restored, and a deleted buyer has to be brought back with POST /buyers/{id}/restore first. Restoring an email another user has taken since fails with 409. Only the buyer themselves or an admin may do this. Send the ETag of the buyer as If-Match to make sure nobody else has changed it since
+// @Tags buyers
+// @Accept json
+// @Produce json
+// @Param id path int true "Buyer ID"
+// @Param version path int true "Version to restore"
+// @Param If-Match header string false "ETag of the buyer as last read"
+// @Success 200 {object} Response{data=models.User}
+// @Header 200 {string} ETag "New version of the buyer"
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Failure 412 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /buyers/{id}/versions/{version}/restore [post]
+func (h *BuyerHandler) RestoreBuyerVersion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid buyer ID")
+		return
+	}
+	version, ok := parseVersion(w, r)
+	if !ok {
+		return
+	}
+
+	buyer, err := h.service.RestoreVersion(r.Context(), uint(id), version, parseIfMatch(r))
+	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if err.Error() == "buyer not found" || err.Error() == "version not found" {
+			SendErrorResponse(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			sendVersionConflict(w, r, err)
+			return
+		}
+		if errors.Is(err, repositories.ErrEmailTaken) {
 			SendErrorResponse(w, http.StatusConflict, err.Error())
 			return
 		}
@@ -116,5 +374,6 @@ func (h *BuyerHandler) DeleteSeller(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	SendSuccessResponse(w, nil, "Seller deleted successfully")
+	setETag(w, buyer.Version)
+	SendSuccessResponse(w, buyer, "Buyer version restored successfully")
 }
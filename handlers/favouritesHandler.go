@@ -1 +1,141 @@
 package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"petstore-api/services"
+
+	"github.com/gorilla/mux"
+)
+
+type FavouriteHandler struct {
+	service services.FavouriteService
+}
+
+func NewFavouriteHandler(service services.FavouriteService) *FavouriteHandler {
+	return &FavouriteHandler{service: service}
+}
+
+// GetFavourites godoc
+// @Summary Get a buyer's favourites
+// @Description Get the pets a buyer has favourited. Deleted pets are left out
+// @Tags favourites
+// @Accept json
+// @Produce json
+// @Param id path int true "Buyer ID"
+// @Success 200 {object} Response{data=models.Favourites}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /buyers/{id}/favourites [get]
+func (h *FavouriteHandler) GetFavourites(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid buyer ID")
+		return
+	}
+
+	favourites, err := h.service.GetFavourites(r.Context(), uint(id))
+	if err != nil {
+		sendFavouriteError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, favourites, "")
+}
+
+// AddFavourite godoc
+// @Summary Favourite a pet
+// @Description Add a pet to the buyer's favourites. Favouriting a pet twice is a no-op
+// @Tags favourites
+// @Accept json
+// @Produce json
+// @Param id path int true "Buyer ID"
+// @Param petId path int true "Pet ID"
+// @Success 200 {object} Response{data=models.Favourites}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /buyers/{id}/favourites/{petId} [put]
+func (h *FavouriteHandler) AddFavourite(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid buyer ID")
+		return
+	}
+	petID, err := strconv.Atoi(vars["petId"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid pet ID")
+		return
+	}
+
+	favourites, err := h.service.AddFavourite(r.Context(), uint(id), uint(petID))
+	if err != nil {
+		sendFavouriteError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, favourites, "Pet added to favourites")
+}
+
+// RemoveFavourite godoc
+// @Summary Unfavourite a pet
+// @Description Remove a pet from the buyer's favourites
+// @Tags favourites
+// @Accept json
+// @Produce json
+// @Param id path int true "Buyer ID"
+// @Param petId path int true "Pet ID"
+// @Success 200 {object} Response{data=models.Favourites}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /buyers/{id}/favourites/{petId} [delete]
+func (h *FavouriteHandler) RemoveFavourite(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid buyer ID")
+		return
+	}
+	petID, err := strconv.Atoi(vars["petId"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid pet ID")
+		return
+	}
+
+	favourites, err := h.service.RemoveFavourite(r.Context(), uint(id), uint(petID))
+	if err != nil {
+		sendFavouriteError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, favourites, "Pet removed from favourites")
+}
+
+func sendFavouriteError(w http.ResponseWriter, err error) {
+	if errors.Is(err, services.ErrForbidden) {
+		SendErrorResponse(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	switch err.Error() {
+	case "buyer not found", "pet not found":
+		SendErrorResponse(w, http.StatusNotFound, err.Error())
+	default:
+		SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+	}
+}
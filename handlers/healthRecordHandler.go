@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"petstore-api/models"
+	"petstore-api/services"
+
+	"github.com/gorilla/mux"
+)
+
+type HealthRecordHandler struct {
+	service services.HealthRecordService
+}
+
+func NewHealthRecordHandler(service services.HealthRecordService) *HealthRecordHandler {
+	return &HealthRecordHandler{service: service}
+}
+
+// GetHealthRecords godoc
+// @Summary Get pet health records
+// @Description Get a pet's vaccinations, treatments and vet checks, most recent first. The records of drafts and withdrawn pets are only found by their seller and admins
+// @Tags health-records
+// @Accept json
+// @Produce json
+// @Param id path int true "Pet ID"
+// @Success 200 {object} Response{data=[]models.HealthRecord}
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Router /pets/{id}/health-records [get]
+func (h *HealthRecordHandler) GetHealthRecords(w http.ResponseWriter, r *http.Request) {
+	petID, ok := parsePetID(w, r)
+	if !ok {
+		return
+	}
+
+	records, err := h.service.GetRecords(r.Context(), petID)
+	if err != nil {
+		sendHealthRecordError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, records, "")
+}
+
+// AddHealthRecord godoc
+// @Summary Add a pet health record
+// @Description Record a vaccination, treatment or vet check. Dates use the YYYY-MM-DD format, date must not be in the future and next_due_date must be after it. Only the owning seller or an admin may do this
+// @Tags health-records
+// @Accept json
+// @Produce json
+// @Param id path int true "Pet ID"
+// @Param record body models.HealthRecordRequest true "Health record"
+// @Success 201 {object} Response{data=models.HealthRecord}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /pets/{id}/health-records [post]
+func (h *HealthRecordHandler) AddHealthRecord(w http.ResponseWriter, r *http.Request) {
+	petID, ok := parsePetID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.HealthRecordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	record, err := h.service.AddRecord(r.Context(), petID, &req)
+	if err != nil {
+		sendHealthRecordError(w, err)
+		return
+	}
+
+	SendCreatedResponse(w, record, "Health record added successfully")
+}
+
+// UpdateHealthRecord godoc
+// @Summary Update a pet health record
+// @Description Replace a health record. Only the owning seller or an admin may do this
+// @Tags health-records
+// @Accept json
+// @Produce json
+// @Param id path int true "Pet ID"
+// @Param recordId path int true "Health record ID"
+// @Param record body models.HealthRecordRequest true "Health record"
+// @Success 200 {object} Response{data=models.HealthRecord}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /pets/{id}/health-records/{recordId} [put]
+func (h *HealthRecordHandler) UpdateHealthRecord(w http.ResponseWriter, r *http.Request) {
+	petID, ok := parsePetID(w, r)
+	if !ok {
+		return
+	}
+	recordID, err := strconv.Atoi(mux.Vars(r)["recordId"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid health record ID")
+		return
+	}
+
+	var req models.HealthRecordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	record, err := h.service.UpdateRecord(r.Context(), petID, uint(recordID), &req)
+	if err != nil {
+		sendHealthRecordError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, record, "Health record updated successfully")
+}
+
+// DeleteHealthRecord godoc
+// @Summary Delete a pet health record
+// @Description Delete a health record. Only the owning seller or an admin may do this
+// @Tags health-records
+// @Accept json
+// @Produce json
+// @Param id path int true "Pet ID"
+// @Param recordId path int true "Health record ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /pets/{id}/health-records/{recordId} [delete]
+func (h *HealthRecordHandler) DeleteHealthRecord(w http.ResponseWriter, r *http.Request) {
+	petID, ok := parsePetID(w, r)
+	if !ok {
+		return
+	}
+	recordID, err := strconv.Atoi(mux.Vars(r)["recordId"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid health record ID")
+		return
+	}
+
+	if err := h.service.DeleteRecord(r.Context(), petID, uint(recordID)); err != nil {
+		sendHealthRecordError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, nil, "Health record deleted successfully")
+}
+
+func sendHealthRecordError(w http.ResponseWriter, err error) {
+	var verr *models.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		SendValidationErrorResponse(w, verr)
+	case errors.Is(err, services.ErrForbidden):
+		SendErrorResponse(w, http.StatusForbidden, err.Error())
+	case err.Error() == "pet not found", err.Error() == "health record not found":
+		SendErrorResponse(w, http.StatusNotFound, err.Error())
+	default:
+		SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+	}
+}
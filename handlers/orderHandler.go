@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"petstore-api/models"
+	"petstore-api/services"
+
+	"github.com/gorilla/mux"
+)
+
+type OrderHandler struct {
+	service services.OrderService
+}
+
+func NewOrderHandler(service services.OrderService) *OrderHandler {
+	return &OrderHandler{service: service}
+}
+
+// Checkout godoc
+// @Summary Check out a buyer's cart
+// @Description Create a pending order from the listed pets in the buyer's cart. Prices are fixed at checkout, the pets are reserved and the cart is emptied
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Buyer ID"
+// @Success 201 {object} Response{data=models.Order}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /buyers/{id}/checkout [post]
+func (h *OrderHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid buyer ID")
+		return
+	}
+
+	order, err := h.service.Checkout(r.Context(), uint(id))
+	if err != nil {
+		sendOrderError(w, err)
+		return
+	}
+
+	SendCreatedResponse(w, order, "Order created successfully")
+}
+
+// GetOrder godoc
+// @Summary Get order by ID
+// @Description Get an order with its items and status history. Visible to the buyer, the sellers involved and admins
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {object} Response{data=models.Order}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /orders/{id} [get]
+func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	order, err := h.service.GetOrder(r.Context(), uint(id))
+	if err != nil {
+		sendOrderError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, order, "")
+}
+
+// GetBuyerOrders godoc
+// @Summary Get a buyer's orders
+// @Description Get all orders placed by a buyer, newest first
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Buyer ID"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "Cursor from pagination.next_cursor of the previous page"
+// @Success 200 {object} Response{data=[]models.Order}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /buyers/{id}/orders [get]
+func (h *OrderHandler) GetBuyerOrders(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid buyer ID")
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	orders, info, err := h.service.GetBuyerOrders(r.Context(), uint(id), page)
+	if err != nil {
+		sendOrderError(w, err)
+		return
+	}
+
+	SendPageResponse(w, orders, info, "")
+}
+
+// GetSellerOrders godoc
+// @Summary Get orders for a seller's pets
+// @Description Get all orders that include at least one of the seller's pets, newest first. Only the seller's own items are returned
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Seller ID"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "Cursor from pagination.next_cursor of the previous page"
+// @Success 200 {object} Response{data=[]models.Order}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /sellers/{id}/orders [get]
+func (h *OrderHandler) GetSellerOrders(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid seller ID")
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	orders, info, err := h.service.GetSellerOrders(r.Context(), uint(id), page)
+	if err != nil {
+		sendOrderError(w, err)
+		return
+	}
+
+	SendPageResponse(w, orders, info, "")
+}
+
+// CancelOrder godoc
+// @Summary Cancel an order
+// @Description Cancel a pending order. Its pets are listed again
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param cancellation body models.CancelOrderRequest false "Optional cancellation note"
+// @Success 200 {object} Response{data=models.Order}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /orders/{id}/cancel [post]
+func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	var req models.CancelOrderRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			SendErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+			return
+		}
+	}
+
+	order, err := h.service.CancelOrder(r.Context(), uint(id), req.Note)
+	if err != nil {
+		sendOrderError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, order, "Order cancelled successfully")
+}
+
+// UpdateOrderStatus godoc
+// @Summary Change an order's status
+// @Description Move an order along pending → paid → handed_over → completed, or to cancelled/refunded. Buyers may cancel or complete, sellers may mark orders of their own pets as handed over, admins may do anything the lifecycle allows. Completing an order marks its pets sold
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param status body models.UpdateOrderStatusRequest true "New status"
+// @Success 200 {object} Response{data=models.Order}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /orders/{id}/status [put]
+func (h *OrderHandler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	var req models.UpdateOrderStatusRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	order, err := h.service.UpdateOrderStatus(r.Context(), uint(id), &req)
+	if err != nil {
+		sendOrderError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, order, "Order status updated successfully")
+}
+
+func sendOrderError(w http.ResponseWriter, err error) {
+	if errors.Is(err, services.ErrForbidden) {
+		SendErrorResponse(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	switch err.Error() {
+	case "cart is empty", "invalid order status", "cart contains pets priced in different currencies", models.ErrInvalidCursor.Error():
+		SendErrorResponse(w, http.StatusBadRequest, err.Error())
+	case "buyer not found", "order not found":
+		SendErrorResponse(w, http.StatusNotFound, err.Error())
+	case "some pets in the cart are no longer available", "order cannot move to this status":
+		SendErrorResponse(w, http.StatusConflict, err.Error())
+	default:
+		SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+	}
+}
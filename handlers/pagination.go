@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"petstore-api/models"
+)
+
+// parsePageRequest reads the limit and cursor query parameters shared by
+// every list endpoint. Limits above models.MaxPageLimit are capped rather
+// than rejected.
+func parsePageRequest(r *http.Request) (models.PageRequest, error) {
+	page := models.PageRequest{Cursor: r.URL.Query().Get("cursor")}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			return page, errors.New("Invalid limit")
+		}
+		page.Limit = limit
+	}
+
+	return page, nil
+}
+
+// parseSort reads a sort parameter such as "-price,created_at": a comma
+// separated list of fields, each ascending unless prefixed with "-". Only
+// the allowed fields may appear, each at most once.
+func parseSort(value string, allowed []string) ([]models.SortField, *models.ValidationError) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var fields []models.SortField
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		field := models.SortField{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+
+		if !slices.Contains(allowed, field.Field) {
+			verr := &models.ValidationError{}
+			verr.Add("sort", fmt.Sprintf("unknown field %q; allowed: %s", field.Field, strings.Join(allowed, ", ")))
+			return nil, verr
+		}
+		if seen[field.Field] {
+			verr := &models.ValidationError{}
+			verr.Add("sort", fmt.Sprintf("field %q given more than once", field.Field))
+			return nil, verr
+		}
+		seen[field.Field] = true
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"petstore-api/models"
+)
+
+func TestParseSort(t *testing.T) {
+	allowed := []string{"price", "age", "created_at", "name"}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    []models.SortField
+		wantErr bool
+	}{
+		{"empty", "", nil, false},
+		{"ascending", "price", []models.SortField{{Field: "price"}}, false},
+		{"descending", "-price", []models.SortField{{Field: "price", Desc: true}}, false},
+		{
+			"several fields keep their order", "-created_at,name, age",
+			[]models.SortField{{Field: "created_at", Desc: true}, {Field: "name"}, {Field: "age"}}, false,
+		},
+		{"unknown field", "colour", nil, true},
+		{"field outside the allowed list", "id", nil, true},
+		{"repeated field", "price,-price", nil, true},
+		{"empty entry", "price,", nil, true},
+		{"lone minus", "-", nil, true},
+		{"field names are case sensitive", "Price", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, verr := parseSort(tt.value, allowed)
+			if tt.wantErr {
+				if verr == nil || verr.Fields["sort"] == "" {
+					t.Fatalf("parseSort(%q) error = %v, want a sort error", tt.value, verr)
+				}
+				return
+			}
+			if verr != nil {
+				t.Fatalf("parseSort(%q) unexpected error %v", tt.value, verr.Fields)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSort(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"petstore-api/payments"
+	"petstore-api/services"
+
+	"github.com/gorilla/mux"
+)
+
+const maxWebhookBodySize = 64 << 10
+
+type PaymentHandler struct {
+	service services.PaymentService
+}
+
+func NewPaymentHandler(service services.PaymentService) *PaymentHandler {
+	return &PaymentHandler{service: service}
+}
+
+// PayOrder godoc
+// @Summary Pay for an order
+// @Description Start a payment for a pending order. The order becomes paid once the provider confirms through the payments webhook
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 201 {object} Response{data=models.Payment}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /orders/{id}/pay [post]
+func (h *PaymentHandler) PayOrder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	payment, err := h.service.PayOrder(r.Context(), uint(id))
+	if err != nil {
+		sendPaymentError(w, err)
+		return
+	}
+
+	SendCreatedResponse(w, payment, "Payment started")
+}
+
+// RefundOrder godoc
+// @Summary Refund an order
+// @Description Ask the provider to refund the order's payment (admin only). The order becomes refunded and its pets listed again once the provider confirms
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {object} Response{data=models.Payment}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /orders/{id}/refund [post]
+func (h *PaymentHandler) RefundOrder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	payment, err := h.service.RefundOrder(r.Context(), uint(id))
+	if err != nil {
+		sendPaymentError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, payment, "Refund requested")
+}
+
+// GetOrderPayments godoc
+// @Summary Get an order's payments
+// @Description Get every payment attempt made for an order
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {object} Response{data=[]models.Payment}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /orders/{id}/payments [get]
+func (h *PaymentHandler) GetOrderPayments(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	list, err := h.service.GetOrderPayments(r.Context(), uint(id))
+	if err != nil {
+		sendPaymentError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, list, "")
+}
+
+// PaymentWebhook godoc
+// @Summary Payment provider webhook
+// @Description Receive a payment event. The raw body must be signed with the shared webhook secret in the X-Payment-Signature header as "sha256=<hex HMAC>". Events are applied at most once per event ID
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param X-Payment-Signature header string true "HMAC-SHA256 signature of the body"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Router /webhooks/payments [post]
+func (h *PaymentHandler) PaymentWebhook(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid webhook payload")
+		return
+	}
+
+	err = h.service.HandleWebhook(payload, r.Header.Get(payments.SignatureHeader))
+	if err != nil {
+		sendPaymentError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, nil, "Event processed")
+}
+
+func sendPaymentError(w http.ResponseWriter, err error) {
+	if errors.Is(err, services.ErrForbidden) {
+		SendErrorResponse(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if errors.Is(err, payments.ErrInvalidSignature) {
+		SendErrorResponse(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	switch err.Error() {
+	case "invalid webhook payload":
+		SendErrorResponse(w, http.StatusBadRequest, err.Error())
+	case "order not found", "payment not found":
+		SendErrorResponse(w, http.StatusNotFound, err.Error())
+	case "only pending orders can be paid", "order already has a payment in progress", "order has no successful payment to refund",
+		"only paid or handed over orders can be refunded":
+		SendErrorResponse(w, http.StatusConflict, err.Error())
+	default:
+		SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+	}
+}
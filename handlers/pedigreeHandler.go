@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"petstore-api/models"
+	"petstore-api/repositories"
+	"petstore-api/services"
+
+	"github.com/gorilla/mux"
+)
+
+type PedigreeHandler struct {
+	service services.PedigreeService
+}
+
+func NewPedigreeHandler(service services.PedigreeService) *PedigreeHandler {
+	return &PedigreeHandler{service: service}
+}
+
+// GetPedigree godoc
+// @Summary Get a pet's pedigree
+// @Description Get the ancestry tree of a pet, following parents that are listed pets as well as external pedigree entries. An ancestor that appears on both sides of the family is shown in both places. The pedigree of drafts and withdrawn pets is only found by their seller and admins
+// @Tags pedigree
+// @Accept json
+// @Produce json
+// @Param id path int true "Pet ID"
+// @Param generations query int false "Number of generations to include (default 3, max 10)"
+// @Success 200 {object} Response{data=models.PedigreeNode}
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Router /pets/{id}/pedigree [get]
+func (h *PedigreeHandler) GetPedigree(w http.ResponseWriter, r *http.Request) {
+	petID, ok := parsePetID(w, r)
+	if !ok {
+		return
+	}
+
+	generations := models.DefaultPedigreeGenerations
+	if v := r.URL.Query().Get("generations"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > models.MaxPedigreeGenerations {
+			var verr models.ValidationError
+			verr.Add("generations", fmt.Sprintf("must be between 1 and %d", models.MaxPedigreeGenerations))
+			SendValidationErrorResponse(w, &verr)
+			return
+		}
+		generations = n
+	}
+
+	tree, err := h.service.GetPedigree(r.Context(), petID, generations)
+	if err != nil {
+		sendPedigreeError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, tree, "")
+}
+
+// SetParents godoc
+// @Summary Set a pet's parents
+// @Description Replace the sire and dam of a pet. Each parent is either a listed pet (pet_id) or a pedigree entry (entry_id) of the same species; an omitted parent is cleared. An assignment that would make the pet its own ancestor is refused. Only the owning seller or an admin may do this. Send the ETag of the pet as If-Match to make sure nobody else has changed it since
+// @Tags pedigree
+// @Accept json
+// @Produce json
+// @Param id path int true "Pet ID"
+// @Param If-Match header string false "ETag of the pet as last read"
+// @Param parents body models.SetParentsRequest true "Parents"
+// @Success 200 {object} Response{data=models.Pet}
+// @Header 200 {string} ETag "New version of the pet"
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Failure 412 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /pets/{id}/parents [put]
+func (h *PedigreeHandler) SetParents(w http.ResponseWriter, r *http.Request) {
+	petID, ok := parsePetID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.SetParentsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	pet, err := h.service.SetParents(r.Context(), petID, &req, parseIfMatch(r))
+	if err != nil {
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			sendVersionConflict(w, r, err)
+			return
+		}
+		sendPedigreeError(w, err)
+		return
+	}
+
+	setETag(w, pet.Version)
+	SendSuccessResponse(w, pet, "Parents updated successfully")
+}
+
+// CreatePedigreeEntry godoc
+// @Summary Create a pedigree entry
+// @Description Register an ancestor that is not listed on the platform, so it can be used as a pet's parent. Its own parents must be existing pedigree entries of the same species
+// @Tags pedigree
+// @Accept json
+// @Produce json
+// @Param entry body models.CreatePedigreeEntryRequest true "Pedigree entry"
+// @Success 201 {object} Response{data=models.PedigreeEntry}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /pedigree-entries [post]
+func (h *PedigreeHandler) CreatePedigreeEntry(w http.ResponseWriter, r *http.Request) {
+	var req models.CreatePedigreeEntryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	entry, err := h.service.CreateEntry(&req)
+	if err != nil {
+		sendPedigreeError(w, err)
+		return
+	}
+
+	SendCreatedResponse(w, entry, "Pedigree entry created successfully")
+}
+
+// GetPedigreeEntry godoc
+// @Summary Get a pedigree entry
+// @Description Get an external pedigree entry by ID
+// @Tags pedigree
+// @Accept json
+// @Produce json
+// @Param id path int true "Pedigree entry ID"
+// @Success 200 {object} Response{data=models.PedigreeEntry}
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Router /pedigree-entries/{id} [get]
+func (h *PedigreeHandler) GetPedigreeEntry(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid pedigree entry ID")
+		return
+	}
+
+	entry, err := h.service.GetEntry(uint(id))
+	if err != nil {
+		sendPedigreeError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, entry, "")
+}
+
+func sendPedigreeError(w http.ResponseWriter, err error) {
+	var verr *models.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		SendValidationErrorResponse(w, verr)
+	case errors.Is(err, services.ErrForbidden):
+		SendErrorResponse(w, http.StatusForbidden, err.Error())
+	case err.Error() == "pet not found", err.Error() == "pedigree entry not found":
+		SendErrorResponse(w, http.StatusNotFound, err.Error())
+	case err.Error() == "a pet cannot be its own ancestor":
+		SendErrorResponse(w, http.StatusBadRequest, err.Error())
+	default:
+		SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"petstore-api/models"
+	"petstore-api/services"
+
+	"github.com/gorilla/mux"
+)
+
+// petExportFlushEvery is how many pets are written between flushes, so
+// clients receive rows while the export is still running.
+const petExportFlushEvery = 500
+
+// petExportColumns are the CSV columns of an export. price is in major
+// units of currency, as the import expects it.
+var petExportColumns = []string{
+	"id", "name", "species", "breed", "age", "price", "currency", "description",
+	"status", "seller_id", "created_at", "updated_at",
+}
+
+// ExportPets godoc
+// @Summary Export pets
+// @Description Stream every pet matching the GET /pets filters as CSV or NDJSON, in ID order. Rows are sent as they are read, so exports of any size can be downloaded without paging. CSV has a header row with the columns id, name, species, breed, age, price (major units), currency, description, status, seller_id, created_at and updated_at; each NDJSON line is a pet as returned by GET /pets/{id}, without photos and favourite counts
+// @Tags pets
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "Export format (default csv)" Enums(csv,ndjson)
+// @Param seller_id query int false "Filter pets by seller ID"
+// @Param species query []string false "Filter by species" collectionFormat(multi)
+// @Param breed query []string false "Filter by breed" collectionFormat(multi)
+// @Param min_price query string false "Minimum price in major units, e.g. 19.99"
+// @Param max_price query string false "Maximum price in major units"
+// @Param currency query string false "Only pets priced in this currency; price bounds use it (default USD when a bound is given)"
+// @Param min_age query int false "Minimum age"
+// @Param max_age query int false "Maximum age"
+// @Param status query []string false "Listing statuses to include (default listed). draft and withdrawn need seller_id set to the caller, or an admin" collectionFormat(multi) Enums(draft,listed,reserved,sold,withdrawn)
+// @Param vaccinated query bool false "Only pets with (true) or without (false) a vaccination that is not yet due again"
+// @Param created_after query string false "Listed at or after this time (RFC 3339 or YYYY-MM-DD)"
+// @Param created_before query string false "Listed before this time (RFC 3339 or YYYY-MM-DD)"
+// @Param include_deleted query bool false "Also include deleted pets that have not been purged yet (admins only)"
+// @Success 200 {string} string "CSV or NDJSON rows"
+// @Failure 400 {object} Response
+// @Failure 403 {object} Response
+// @Failure 500 {object} Response
+// @Router /pets/export [get]
+func (h *PetHandler) ExportPets(w http.ResponseWriter, r *http.Request) {
+	filter, paramErr := parsePetFilter(r)
+	if paramErr != nil {
+		SendValidationErrorResponse(w, paramErr)
+		return
+	}
+
+	h.exportPets(w, r, filter, "pets")
+}
+
+// ExportSellerPets godoc
+// @Summary Export a seller's pets
+// @Description Stream the pets of one seller as GET /pets/export does. The seller may export their drafts and withdrawn pets through status; others only see public statuses
+// @Tags pets
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param id path int true "Seller ID"
+// @Param format query string false "Export format (default csv)" Enums(csv,ndjson)
+// @Param species query []string false "Filter by species" collectionFormat(multi)
+// @Param breed query []string false "Filter by breed" collectionFormat(multi)
+// @Param min_price query string false "Minimum price in major units, e.g. 19.99"
+// @Param max_price query string false "Maximum price in major units"
+// @Param currency query string false "Only pets priced in this currency; price bounds use it (default USD when a bound is given)"
+// @Param min_age query int false "Minimum age"
+// @Param max_age query int false "Maximum age"
+// @Param status query []string false "Listing statuses to include (default listed). draft and withdrawn are only for the seller themselves or an admin" collectionFormat(multi) Enums(draft,listed,reserved,sold,withdrawn)
+// @Param vaccinated query bool false "Only pets with (true) or without (false) a vaccination that is not yet due again"
+// @Param created_after query string false "Listed at or after this time (RFC 3339 or YYYY-MM-DD)"
+// @Param created_before query string false "Listed before this time (RFC 3339 or YYYY-MM-DD)"
+// @Param include_deleted query bool false "Also include deleted pets that have not been purged yet (admins only)"
+// @Success 200 {string} string "CSV or NDJSON rows"
+// @Failure 400 {object} Response
+// @Failure 403 {object} Response
+// @Failure 500 {object} Response
+// @Router /sellers/{id}/pets/export [get]
+func (h *PetHandler) ExportSellerPets(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid seller ID")
+		return
+	}
+
+	filter, paramErr := parsePetFilter(r)
+	if paramErr != nil {
+		SendValidationErrorResponse(w, paramErr)
+		return
+	}
+	sellerID := uint(id)
+	filter.SellerID = &sellerID
+
+	h.exportPets(w, r, filter, fmt.Sprintf("seller-%d-pets", sellerID))
+}
+
+// exportPets writes the response headers only once the first pet arrives,
+// or the export finishes empty, so that errors found before then still get
+// a proper error response. Later errors can only cut the body short.
+func (h *PetHandler) exportPets(w http.ResponseWriter, r *http.Request, filter models.PetFilter, filename string) {
+	var out petExportWriter
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "csv":
+		out = newPetCSVWriter(w)
+		filename += ".csv"
+	case "ndjson":
+		out = newPetNDJSONWriter(w)
+		filename += ".ndjson"
+	default:
+		var verr models.ValidationError
+		verr.Add("format", "must be csv or ndjson")
+		SendValidationErrorResponse(w, &verr)
+		return
+	}
+
+	started := false
+	start := func() error {
+		started = true
+		w.Header().Set("Content-Type", out.contentType())
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		w.WriteHeader(http.StatusOK)
+		return out.begin()
+	}
+
+	count := 0
+	err := h.service.ExportPets(r.Context(), filter, func(pet *models.Pet) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := out.write(pet); err != nil {
+			return err
+		}
+		count++
+		if count%petExportFlushEvery == 0 {
+			return flushExport(w, out)
+		}
+		return nil
+	})
+	if err == nil && !started {
+		err = start()
+	}
+	if err == nil {
+		err = flushExport(w, out)
+	}
+	if err == nil {
+		return
+	}
+
+	if started {
+		log.Printf("pet export stopped after %d pets: %v", count, err)
+		return
+	}
+	var verr *models.ValidationError
+	if errors.As(err, &verr) {
+		SendValidationErrorResponse(w, verr)
+		return
+	}
+	if errors.Is(err, services.ErrForbidden) {
+		SendErrorResponse(w, http.StatusForbidden, err.Error())
+		return
+	}
+	SendErrorResponse(w, http.StatusInternalServerError, "Failed to export pets")
+}
+
+func flushExport(w http.ResponseWriter, out petExportWriter) error {
+	if err := out.flush(); err != nil {
+		return err
+	}
+	err := http.NewResponseController(w).Flush()
+	if errors.Is(err, http.ErrNotSupported) {
+		return nil
+	}
+	return err
+}
+
+// petExportWriter encodes pets in one export format. Output is buffered
+// until flush.
+type petExportWriter interface {
+	contentType() string
+	begin() error
+	write(pet *models.Pet) error
+	flush() error
+}
+
+type petCSVWriter struct {
+	csv *csv.Writer
+}
+
+func newPetCSVWriter(w io.Writer) *petCSVWriter {
+	return &petCSVWriter{csv: csv.NewWriter(w)}
+}
+
+func (c *petCSVWriter) contentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (c *petCSVWriter) begin() error {
+	return c.csv.Write(petExportColumns)
+}
+
+func (c *petCSVWriter) write(pet *models.Pet) error {
+	return c.csv.Write([]string{
+		strconv.FormatUint(uint64(pet.ID), 10),
+		pet.Name,
+		pet.Species,
+		pet.Breed,
+		strconv.Itoa(pet.Age),
+		pet.Price.MajorUnits(),
+		pet.Price.Currency,
+		pet.Description,
+		string(pet.Status),
+		strconv.FormatUint(uint64(pet.SellerID), 10),
+		pet.CreatedAt.UTC().Format(time.RFC3339),
+		pet.UpdatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+func (c *petCSVWriter) flush() error {
+	c.csv.Flush()
+	return c.csv.Error()
+}
+
+type petNDJSONWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func newPetNDJSONWriter(w io.Writer) *petNDJSONWriter {
+	buf := bufio.NewWriter(w)
+	return &petNDJSONWriter{buf: buf, enc: json.NewEncoder(buf)}
+}
+
+func (n *petNDJSONWriter) contentType() string {
+	return "application/x-ndjson"
+}
+
+func (n *petNDJSONWriter) begin() error {
+	return nil
+}
+
+// write relies on json.Encoder ending every value with a newline.
+func (n *petNDJSONWriter) write(pet *models.Pet) error {
+	return n.enc.Encode(pet)
+}
+
+func (n *petNDJSONWriter) flush() error {
+	return n.buf.Flush()
+}
@@ -2,10 +2,15 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"petstore-api/models"
+	"petstore-api/repositories"
 	"petstore-api/services"
 
 	"github.com/gorilla/mux"
@@ -21,49 +26,203 @@ func NewPetHandler(service services.PetService) *PetHandler {
 
 // GetPets godoc
 // @Summary Get all pets
-// @Description Get list of all pets with optional seller inclusion and filtering
+// @Description Get list of all pets with optional seller inclusion and filtering. Only listed pets are returned unless status says otherwise. species, breed and status accept several values, either repeated or comma separated
 // @Tags pets
 // @Accept json
 // @Produce json
 // @Param include_seller query bool false "Include seller information in response"
 // @Param seller_id query int false "Filter pets by seller ID"
+// @Param species query []string false "Filter by species" collectionFormat(multi)
+// @Param breed query []string false "Filter by breed" collectionFormat(multi)
+// @Param min_price query string false "Minimum price in major units, e.g. 19.99"
+// @Param max_price query string false "Maximum price in major units"
+// @Param currency query string false "Only pets priced in this currency; price bounds use it (default USD when a bound is given)"
+// @Param min_age query int false "Minimum age"
+// @Param max_age query int false "Maximum age"
+// @Param status query []string false "Listing statuses to include (default listed). draft and withdrawn need seller_id set to the caller, or an admin" collectionFormat(multi) Enums(draft,listed,reserved,sold,withdrawn)
+// @Param vaccinated query bool false "Only pets with (true) or without (false) a vaccination that is not yet due again"
+// @Param created_after query string false "Listed at or after this time (RFC 3339 or YYYY-MM-DD)"
+// @Param created_before query string false "Listed before this time (RFC 3339 or YYYY-MM-DD)"
+// @Param include_deleted query bool false "Also include deleted pets that have not been purged yet (admins only)"
+// @Param sort query string false "Comma separated sort fields, '-' prefix for descending: name, species, breed, age, price, created_at, updated_at" example(-price,created_at)
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "Cursor from pagination.next_cursor of the previous page; only valid with the same sort"
 // @Success 200 {object} Response{data=[]models.Pet}
 // @Failure 400 {object} Response
+// @Failure 403 {object} Response
 // @Failure 500 {object} Response
 // @Router /pets [get]
 func (h *PetHandler) GetPets(w http.ResponseWriter, r *http.Request) {
 	includeSeller := r.URL.Query().Get("include_seller") == "true"
 
-	var sellerID *uint
-	if sellerIDStr := r.URL.Query().Get("seller_id"); sellerIDStr != "" {
-		id, err := strconv.Atoi(sellerIDStr)
-		if err != nil {
-			SendErrorResponse(w, http.StatusBadRequest, "Invalid seller ID")
+	filter, paramErr := parsePetFilter(r)
+	if paramErr != nil {
+		SendValidationErrorResponse(w, paramErr)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	page.Sort, paramErr = parseSort(r.URL.Query().Get("sort"), models.PetSortFields)
+	if paramErr != nil {
+		SendValidationErrorResponse(w, paramErr)
+		return
+	}
+
+	pets, info, err := h.service.GetAllPets(r.Context(), includeSeller, filter, page)
+	if err != nil {
+		var verr *models.ValidationError
+		if errors.As(err, &verr) {
+			SendValidationErrorResponse(w, verr)
+			return
+		}
+		if errors.Is(err, services.ErrForbidden) {
+			SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, models.ErrInvalidCursor) {
+			SendErrorResponse(w, http.StatusBadRequest, err.Error())
 			return
 		}
-		sellerIDUint := uint(id)
-		sellerID = &sellerIDUint
+		SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch pets")
+		return
 	}
 
-	pets, err := h.service.GetAllPets(includeSeller, sellerID)
+	SendPageResponse(w, pets, info, "")
+}
+
+// SearchPets godoc
+// @Summary Search pets
+// @Description Full-text search over pet names, species, breeds and descriptions, best matches first. Names, species and breeds weigh more than descriptions. q accepts web-search syntax: quoted phrases, "or" and a leading "-" to exclude a word. The GET /pets filters apply as well
+// @Tags pets
+// @Accept json
+// @Produce json
+// @Param q query string true "Search text" example(golden hypoallergenic)
+// @Param species query []string false "Filter by species" collectionFormat(multi)
+// @Param breed query []string false "Filter by breed" collectionFormat(multi)
+// @Param min_price query string false "Minimum price in major units, e.g. 19.99"
+// @Param max_price query string false "Maximum price in major units"
+// @Param currency query string false "Only pets priced in this currency; price bounds use it (default USD when a bound is given)"
+// @Param min_age query int false "Minimum age"
+// @Param max_age query int false "Maximum age"
+// @Param status query []string false "Listing statuses to include (default listed). draft and withdrawn need seller_id set to the caller, or an admin" collectionFormat(multi) Enums(draft,listed,reserved,sold,withdrawn)
+// @Param vaccinated query bool false "Only pets with (true) or without (false) a vaccination that is not yet due again"
+// @Param include_deleted query bool false "Also include deleted pets that have not been purged yet (admins only)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "Cursor from pagination.next_cursor of the previous page"
+// @Success 200 {object} Response{data=[]models.PetSearchResult}
+// @Failure 400 {object} Response
+// @Failure 403 {object} Response
+// @Failure 500 {object} Response
+// @Router /pets/search [get]
+func (h *PetHandler) SearchPets(w http.ResponseWriter, r *http.Request) {
+	filter, paramErr := parsePetFilter(r)
+	if paramErr != nil {
+		SendValidationErrorResponse(w, paramErr)
+		return
+	}
+
+	page, err := parsePageRequest(r)
 	if err != nil {
-		SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch pets")
+		SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	results, info, err := h.service.SearchPets(r.Context(), r.URL.Query().Get("q"), filter, page)
+	if err != nil {
+		var verr *models.ValidationError
+		if errors.As(err, &verr) {
+			SendValidationErrorResponse(w, verr)
+			return
+		}
+		if errors.Is(err, services.ErrForbidden) {
+			SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, models.ErrInvalidCursor) {
+			SendErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		SendErrorResponse(w, http.StatusInternalServerError, "Failed to search pets")
+		return
+	}
+
+	SendPageResponse(w, results, info, "")
+}
+
+// GetPetFacets godoc
+// @Summary Get catalog facet counts
+// @Description Count the pets matching the GET /pets filters by species, breed, status, seller, price bucket and age bucket. Each bucket runs from its bound up to the next one; the last is open-ended. The status counts ignore the status filter, so they show every status the caller may see. Price buckets only count pets priced in the filter currency (default USD)
+// @Tags pets
+// @Accept json
+// @Produce json
+// @Param seller_id query int false "Filter pets by seller ID"
+// @Param species query []string false "Filter by species" collectionFormat(multi)
+// @Param breed query []string false "Filter by breed" collectionFormat(multi)
+// @Param min_price query string false "Minimum price in major units, e.g. 19.99"
+// @Param max_price query string false "Maximum price in major units"
+// @Param currency query string false "Only pets priced in this currency; price bounds and buckets use it"
+// @Param min_age query int false "Minimum age"
+// @Param max_age query int false "Maximum age"
+// @Param status query []string false "Listing statuses to include (default listed). draft and withdrawn need seller_id set to the caller, or an admin" collectionFormat(multi) Enums(draft,listed,reserved,sold,withdrawn)
+// @Param vaccinated query bool false "Only pets with (true) or without (false) a vaccination that is not yet due again"
+// @Param created_after query string false "Listed at or after this time (RFC 3339 or YYYY-MM-DD)"
+// @Param created_before query string false "Listed before this time (RFC 3339 or YYYY-MM-DD)"
+// @Param include_deleted query bool false "Also include deleted pets that have not been purged yet (admins only)"
+// @Param price_buckets query string false "Increasing price bucket bounds in major units (default 0,50,100,250,500,1000)" example(0,100,500)
+// @Param age_buckets query string false "Increasing age bucket bounds (default 0,1,3,7,12)" example(0,2,5)
+// @Success 200 {object} Response{data=models.PetFacets}
+// @Failure 400 {object} Response
+// @Failure 403 {object} Response
+// @Failure 500 {object} Response
+// @Router /pets/facets [get]
+func (h *PetHandler) GetPetFacets(w http.ResponseWriter, r *http.Request) {
+	filter, paramErr := parsePetFilter(r)
+	if paramErr != nil {
+		SendValidationErrorResponse(w, paramErr)
+		return
+	}
+
+	options, paramErr := parseFacetOptions(r, filter.Currency)
+	if paramErr != nil {
+		SendValidationErrorResponse(w, paramErr)
+		return
+	}
+
+	facets, err := h.service.GetPetFacets(r.Context(), filter, options)
+	if err != nil {
+		var verr *models.ValidationError
+		if errors.As(err, &verr) {
+			SendValidationErrorResponse(w, verr)
+			return
+		}
+		if errors.Is(err, services.ErrForbidden) {
+			SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
+		SendErrorResponse(w, http.StatusInternalServerError, "Failed to count pets")
 		return
 	}
 
-	SendSuccessResponse(w, pets, "")
+	SendSuccessResponse(w, facets, "")
 }
 
 // GetPet godoc
 // @Summary Get pet by ID
-// @Description Get a single pet by ID with optional seller inclusion
+// @Description Get a single pet by ID with optional seller inclusion. Drafts and withdrawn pets are only found by their seller and admins
 // @Tags pets
 // @Accept json
 // @Produce json
 // @Param id path int true "Pet ID"
 // @Param include_seller query bool false "Include seller information in response"
+// @Param include_deleted query bool false "Also find the pet if it was deleted and has not been purged yet (admins only)"
 // @Success 200 {object} Response{data=models.Pet}
+// @Header 200 {string} ETag "Version of the pet, for If-Match on PUT /pets/{id}"
 // @Failure 400 {object} Response
+// @Failure 403 {object} Response
 // @Failure 404 {object} Response
 // @Failure 500 {object} Response
 // @Router /pets/{id} [get]
@@ -76,9 +235,14 @@ func (h *PetHandler) GetPet(w http.ResponseWriter, r *http.Request) {
 	}
 
 	includeSeller := r.URL.Query().Get("include_seller") == "true"
+	includeDeleted := r.URL.Query().Get("include_deleted") == "true"
 
-	pet, err := h.service.GetPetByID(uint(id), includeSeller)
+	pet, err := h.service.GetPetByID(r.Context(), uint(id), includeSeller, includeDeleted)
 	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
 		if err.Error() == "pet not found" {
 			SendErrorResponse(w, http.StatusNotFound, err.Error())
 			return
@@ -87,18 +251,22 @@ func (h *PetHandler) GetPet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setETag(w, pet.Version)
 	SendSuccessResponse(w, pet, "")
 }
 
 // CreatePet godoc
 // @Summary Create a new pet
-// @Description Create a new pet with the provided information
+// @Description Create a new pet with the provided information. seller_id defaults to the caller; only admins may list pets for another seller. Species and breed must be known to GET /species, and names or aliases are stored under their canonical name
 // @Tags pets
 // @Accept json
 // @Produce json
 // @Param pet body models.CreatePetRequest true "Pet creation data"
 // @Success 201 {object} Response{data=models.Pet}
 // @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Security BearerAuth
 // @Router /pets [post]
 func (h *PetHandler) CreatePet(w http.ResponseWriter, r *http.Request) {
 	var req models.CreatePetRequest
@@ -109,8 +277,17 @@ func (h *PetHandler) CreatePet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pet, err := h.service.CreatePet(&req)
+	pet, err := h.service.CreatePet(r.Context(), &req)
 	if err != nil {
+		var verr *models.ValidationError
+		if errors.As(err, &verr) {
+			SendValidationErrorResponse(w, verr)
+			return
+		}
+		if errors.Is(err, services.ErrForbidden) {
+			SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
 		SendErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -120,16 +297,23 @@ func (h *PetHandler) CreatePet(w http.ResponseWriter, r *http.Request) {
 
 // UpdatePet godoc
 // @Summary Update pet
-// @Description Update an existing pet's information
+// @Description Update an existing pet's information. Only the owning seller or an admin may do this, and only admins may reassign seller_id. Species and breed are normalized as on creation, and the breed must belong to the species. Send the ETag of the pet as If-Match to make sure nobody else has changed it since; an update that loses against a concurrent change fails with 409 instead of overwriting it
 // @Tags pets
 // @Accept json
 // @Produce json
 // @Param id path int true "Pet ID"
+// @Param If-Match header string false "ETag of the pet as last read"
 // @Param pet body models.UpdatePetRequest true "Pet update data"
 // @Success 200 {object} Response{data=models.Pet}
+// @Header 200 {string} ETag "New version of the pet"
 // @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
 // @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Failure 412 {object} Response
 // @Failure 500 {object} Response
+// @Security BearerAuth
 // @Router /pets/{id} [put]
 func (h *PetHandler) UpdatePet(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -146,30 +330,203 @@ func (h *PetHandler) UpdatePet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pet, err := h.service.UpdatePet(uint(id), &req)
+	pet, err := h.service.UpdatePet(r.Context(), uint(id), &req, parseIfMatch(r))
 	if err != nil {
-		if err.Error() == "pet not found" {
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			sendVersionConflict(w, r, err)
+			return
+		}
+		var verr *models.ValidationError
+		if errors.As(err, &verr) {
+			SendValidationErrorResponse(w, verr)
+			return
+		}
+		if errors.Is(err, services.ErrForbidden) {
+			SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if err.Error() == "pet not found" || errors.Is(err, services.ErrSellerNotFound) {
 			SendErrorResponse(w, http.StatusNotFound, err.Error())
 			return
 		}
+		if errors.Is(err, services.ErrNotSeller) || errors.Is(err, models.ErrInvalidCurrency) || errors.Is(err, models.ErrNegativeAmount) ||
+			err.Error() == "age must not be negative" {
+			SendErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		SendErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	setETag(w, pet.Version)
 	SendSuccessResponse(w, pet, "Pet updated successfully")
 }
 
+// UpdatePetStatus godoc
+// @Summary Change a pet's listing status
+// @Description Move a pet between draft, listed and withdrawn. Reserved and sold are set by orders and cannot be changed here. Only the owning seller or an admin may do this. Send the ETag of the pet as If-Match to make sure nobody else has changed it since
+// @Tags pets
+// @Accept json
+// @Produce json
+// @Param id path int true "Pet ID"
+// @Param If-Match header string false "ETag of the pet as last read"
+// @Param status body models.UpdatePetStatusRequest true "New status"
+// @Success 200 {object} Response{data=models.Pet}
+// @Header 200 {string} ETag "New version of the pet"
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Failure 412 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /pets/{id}/status [put]
+func (h *PetHandler) UpdatePetStatus(w http.ResponseWriter, r *http.Request) {
+	petID, ok := parsePetID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpdatePetStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	pet, err := h.service.ChangeStatus(r.Context(), petID, &req, parseIfMatch(r))
+	if err != nil {
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			sendVersionConflict(w, r, err)
+			return
+		}
+		sendPetStatusError(w, err)
+		return
+	}
+
+	setETag(w, pet.Version)
+	SendSuccessResponse(w, pet, "Pet status updated successfully")
+}
+
+// GetPetStatusHistory godoc
+// @Summary Get a pet's status history
+// @Description Get every status change of a pet, oldest first. Only the owning seller or an admin may do this
+// @Tags pets
+// @Accept json
+// @Produce json
+// @Param id path int true "Pet ID"
+// @Success 200 {object} Response{data=[]models.PetStatusHistory}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /pets/{id}/status-history [get]
+func (h *PetHandler) GetPetStatusHistory(w http.ResponseWriter, r *http.Request) {
+	petID, ok := parsePetID(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := h.service.GetStatusHistory(r.Context(), petID)
+	if err != nil {
+		sendPetStatusError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, history, "")
+}
+
+// GetPetVersions godoc
+// @Summary Get a pet's version history
+// @Description Get every recorded create, update, delete and restore of a pet, oldest first, with who made it and the fields it changed. The history of a deleted pet stays available. Only the pet's seller or an admin may do this
+// @Tags pets
+// @Accept json
+// @Produce json
+// @Param id path int true "Pet ID"
+// @Success 200 {object} Response{data=[]models.AuditEntry}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /pets/{id}/versions [get]
+func (h *PetHandler) GetPetVersions(w http.ResponseWriter, r *http.Request) {
+	petID, ok := parsePetID(w, r)
+	if !ok {
+		return
+	}
+
+	versions, err := h.service.GetVersions(r.Context(), petID)
+	if err != nil {
+		sendPetVersionError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, versions, "")
+}
+
+// RestorePetVersion godoc
+// @Summary Restore a pet version
+// @Description Put back the name, species, breed, age, price, description and seller a pet had in an earlier version. The status is not restored. Moving the pet back to another seller needs an admin. A deleted pet has to be brought back with POST /pets/{id}/restore first. Only the pet's seller or an admin may do this. Send the ETag of the pet as If-Match to make sure nobody else has changed it since
+// @Tags pets
+// @Accept json
+// @Produce json
+// @Param id path int true "Pet ID"
+// @Param version path int true "Version to restore"
+// @Param If-Match header string false "ETag of the pet as last read"
+// @Success 200 {object} Response{data=models.Pet}
+// @Header 200 {string} ETag "New version of the pet"
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Failure 412 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /pets/{id}/versions/{version}/restore [post]
+func (h *PetHandler) RestorePetVersion(w http.ResponseWriter, r *http.Request) {
+	petID, ok := parsePetID(w, r)
+	if !ok {
+		return
+	}
+	version, ok := parseVersion(w, r)
+	if !ok {
+		return
+	}
+
+	pet, err := h.service.RestoreVersion(r.Context(), petID, version, parseIfMatch(r))
+	if err != nil {
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			sendVersionConflict(w, r, err)
+			return
+		}
+		sendPetVersionError(w, err)
+		return
+	}
+
+	setETag(w, pet.Version)
+	SendSuccessResponse(w, pet, "Pet version restored successfully")
+}
+
 // DeletePet godoc
 // @Summary Delete pet
-// @Description Delete a pet by ID
+// @Description Delete a pet by ID. The pet is hidden at once and can be brought back with POST /pets/{id}/restore until it is purged after the retention period. A pet reserved by an open order cannot be deleted until the order is cancelled or completed. Only the owning seller or an admin may do this
 // @Tags pets
 // @Accept json
 // @Produce json
 // @Param id path int true "Pet ID"
 // @Success 200 {object} Response
 // @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
 // @Failure 404 {object} Response
+// @Failure 409 {object} Response
 // @Failure 500 {object} Response
+// @Security BearerAuth
 // @Router /pets/{id} [delete]
 func (h *PetHandler) DeletePet(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -179,15 +536,282 @@ func (h *PetHandler) DeletePet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.DeletePet(uint(id))
+	err = h.service.DeletePet(r.Context(), uint(id))
 	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
 		if err.Error() == "pet not found" {
 			SendErrorResponse(w, http.StatusNotFound, err.Error())
 			return
 		}
+		if errors.Is(err, repositories.ErrPetReserved) {
+			SendErrorResponse(w, http.StatusConflict, err.Error())
+			return
+		}
 		SendErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	SendSuccessResponse(w, nil, "Pet deleted successfully")
 }
+
+// RestorePet godoc
+// @Summary Restore a deleted pet
+// @Description Bring back a deleted pet that has not been purged yet, with its photos and records. Its seller must still hold the seller role. Only the owning seller or an admin may do this
+// @Tags pets
+// @Accept json
+// @Produce json
+// @Param id path int true "Pet ID"
+// @Success 200 {object} Response{data=models.Pet}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /pets/{id}/restore [post]
+func (h *PetHandler) RestorePet(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePetID(w, r)
+	if !ok {
+		return
+	}
+
+	pet, err := h.service.RestorePet(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrForbidden):
+			SendErrorResponse(w, http.StatusForbidden, err.Error())
+		case err.Error() == "pet not found":
+			SendErrorResponse(w, http.StatusNotFound, err.Error())
+		case err.Error() == "pet is not deleted",
+			errors.Is(err, services.ErrSellerNotFound),
+			errors.Is(err, services.ErrNotSeller):
+			SendErrorResponse(w, http.StatusConflict, err.Error())
+		default:
+			SendErrorResponse(w, http.StatusInternalServerError, "Failed to restore pet")
+		}
+		return
+	}
+
+	SendSuccessResponse(w, pet, "Pet restored successfully")
+}
+
+// parsePetFilter reads the GET /pets filter parameters and reports every
+// malformed one at once.
+func parsePetFilter(r *http.Request) (models.PetFilter, *models.ValidationError) {
+	query := r.URL.Query()
+	var filter models.PetFilter
+	var verr models.ValidationError
+
+	if v := query.Get("seller_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 0)
+		if err != nil || id == 0 {
+			verr.Add("seller_id", "must be a positive integer")
+		} else {
+			sellerID := uint(id)
+			filter.SellerID = &sellerID
+		}
+	}
+
+	filter.Species = multiValue(query["species"])
+	filter.Breeds = multiValue(query["breed"])
+
+	if v := query.Get("currency"); v != "" {
+		filter.Currency = strings.ToUpper(strings.TrimSpace(v))
+		if err := (models.Money{Currency: filter.Currency}).Validate(); err != nil {
+			verr.Add("currency", err.Error())
+		}
+	}
+	for _, bound := range []struct {
+		name   string
+		target **models.Money
+	}{{"min_price", &filter.MinPrice}, {"max_price", &filter.MaxPrice}} {
+		v := query.Get(bound.name)
+		if _, badCurrency := verr.Fields["currency"]; v == "" || badCurrency {
+			continue
+		}
+		price, err := models.ParseMoney(v, filter.Currency)
+		if err == nil {
+			err = price.Validate()
+		}
+		if err != nil {
+			verr.Add(bound.name, "must be a non-negative amount with at most the currency's decimal places")
+			continue
+		}
+		filter.Currency = price.Currency
+		*bound.target = &price
+	}
+
+	for _, bound := range []struct {
+		name   string
+		target **int
+	}{{"min_age", &filter.MinAge}, {"max_age", &filter.MaxAge}} {
+		v := query.Get(bound.name)
+		if v == "" {
+			continue
+		}
+		age, err := strconv.Atoi(v)
+		if err != nil || age < 0 {
+			verr.Add(bound.name, "must be a non-negative integer")
+			continue
+		}
+		*bound.target = &age
+	}
+
+	for _, v := range multiValue(query["status"]) {
+		status := models.PetStatus(strings.ToLower(v))
+		if !status.Valid() {
+			verr.Add("status", "must be one of draft, listed, reserved, sold, withdrawn")
+			break
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+	if v := query.Get("vaccinated"); v != "" {
+		vaccinated, err := strconv.ParseBool(v)
+		if err != nil {
+			verr.Add("vaccinated", "must be true or false")
+		} else {
+			filter.Vaccinated = &vaccinated
+		}
+	}
+	if v := query.Get("include_deleted"); v != "" {
+		includeDeleted, err := strconv.ParseBool(v)
+		if err != nil {
+			verr.Add("include_deleted", "must be true or false")
+		} else {
+			filter.IncludeDeleted = includeDeleted
+		}
+	}
+
+	for _, bound := range []struct {
+		name   string
+		target **time.Time
+	}{{"created_after", &filter.CreatedAfter}, {"created_before", &filter.CreatedBefore}} {
+		v := query.Get(bound.name)
+		if v == "" {
+			continue
+		}
+		t, err := parseTimeParam(v)
+		if err != nil {
+			verr.Add(bound.name, "must be an RFC 3339 timestamp or a YYYY-MM-DD date")
+			continue
+		}
+		*bound.target = &t
+	}
+
+	if verr.HasErrors() {
+		return filter, &verr
+	}
+	return filter, nil
+}
+
+// maxFacetBuckets keeps a facet request from asking for an unbounded
+// number of buckets.
+const maxFacetBuckets = 20
+
+// parseFacetOptions reads the price_buckets and age_buckets parameters.
+// Price bounds are in the filter's currency. Missing parameters are left
+// nil so the service falls back to its defaults.
+func parseFacetOptions(r *http.Request, currency string) (models.PetFacetOptions, *models.ValidationError) {
+	query := r.URL.Query()
+	var options models.PetFacetOptions
+	var verr models.ValidationError
+
+	if v := query.Get("price_buckets"); v != "" {
+		for _, part := range strings.Split(v, ",") {
+			bound, err := models.ParseMoney(part, currency)
+			if err == nil {
+				err = bound.Validate()
+			}
+			if err != nil {
+				verr.Add("price_buckets", "must be non-negative amounts with at most the currency's decimal places")
+				break
+			}
+			if n := len(options.PriceBounds); n > 0 && bound.Amount <= options.PriceBounds[n-1].Amount {
+				verr.Add("price_buckets", "bounds must be strictly increasing")
+				break
+			}
+			options.PriceBounds = append(options.PriceBounds, bound)
+		}
+		if len(options.PriceBounds) > maxFacetBuckets {
+			verr.Add("price_buckets", fmt.Sprintf("at most %d bounds are allowed", maxFacetBuckets))
+		}
+	}
+
+	if v := query.Get("age_buckets"); v != "" {
+		for _, part := range strings.Split(v, ",") {
+			bound, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || bound < 0 {
+				verr.Add("age_buckets", "must be non-negative integers")
+				break
+			}
+			if n := len(options.AgeBounds); n > 0 && bound <= options.AgeBounds[n-1] {
+				verr.Add("age_buckets", "bounds must be strictly increasing")
+				break
+			}
+			options.AgeBounds = append(options.AgeBounds, bound)
+		}
+		if len(options.AgeBounds) > maxFacetBuckets {
+			verr.Add("age_buckets", fmt.Sprintf("at most %d bounds are allowed", maxFacetBuckets))
+		}
+	}
+
+	if verr.HasErrors() {
+		return options, &verr
+	}
+	return options, nil
+}
+
+// multiValue accepts both repeated parameters and comma separated lists.
+func multiValue(values []string) []string {
+	var result []string
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+	return result
+}
+
+func parseTimeParam(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+func sendPetStatusError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrForbidden):
+		SendErrorResponse(w, http.StatusForbidden, err.Error())
+	case err.Error() == "pet not found":
+		SendErrorResponse(w, http.StatusNotFound, err.Error())
+	case err.Error() == "invalid pet status", err.Error() == "pet cannot move to this status":
+		SendErrorResponse(w, http.StatusBadRequest, err.Error())
+	default:
+		SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func sendPetVersionError(w http.ResponseWriter, err error) {
+	var verr *models.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		SendValidationErrorResponse(w, verr)
+	case errors.Is(err, services.ErrForbidden):
+		SendErrorResponse(w, http.StatusForbidden, err.Error())
+	case err.Error() == "pet not found", err.Error() == "version not found":
+		SendErrorResponse(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrSellerNotFound), errors.Is(err, services.ErrNotSeller):
+		SendErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, repositories.ErrVersionConflict):
+		SendErrorResponse(w, http.StatusConflict, err.Error())
+	default:
+		SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"petstore-api/models"
+)
+
+func TestParsePetFilter(t *testing.T) {
+	sellerID := uint(7)
+	two, five := 2, 5
+	vaccinated := true
+	after := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		query      string
+		want       models.PetFilter
+		wantFields []string
+	}{
+		{"no parameters", "", models.PetFilter{}, nil},
+		{
+			"repeated and comma separated values", "species=dog,cat&species=%20bird%20&breed=beagle&breed=",
+			models.PetFilter{Species: []string{"dog", "cat", "bird"}, Breeds: []string{"beagle"}}, nil,
+		},
+		{"seller", "seller_id=7", models.PetFilter{SellerID: &sellerID}, nil},
+		{"seller zero", "seller_id=0", models.PetFilter{}, []string{"seller_id"}},
+		{"seller not a number", "seller_id=abc", models.PetFilter{}, []string{"seller_id"}},
+		{
+			"prices default to USD", "min_price=10&max_price=19.99",
+			models.PetFilter{Currency: "USD", MinPrice: &models.Money{Amount: 1000, Currency: "USD"}, MaxPrice: &models.Money{Amount: 1999, Currency: "USD"}}, nil,
+		},
+		{
+			"prices in the given currency", "currency=jpy&min_price=500",
+			models.PetFilter{Currency: "JPY", MinPrice: &models.Money{Amount: 500, Currency: "JPY"}}, nil,
+		},
+		{"negative price", "min_price=-1", models.PetFilter{}, []string{"min_price"}},
+		{"too many decimals", "max_price=1.999", models.PetFilter{}, []string{"max_price"}},
+		{"invalid currency skips the prices", "currency=dollars&min_price=1", models.PetFilter{Currency: "DOLLARS"}, []string{"currency"}},
+		{"ages", "min_age=2&max_age=5", models.PetFilter{MinAge: &two, MaxAge: &five}, nil},
+		{"negative age", "min_age=-1&max_age=x", models.PetFilter{}, []string{"min_age", "max_age"}},
+		{
+			"statuses are lower cased", "status=Listed,sold",
+			models.PetFilter{Statuses: []models.PetStatus{models.PetStatusListed, models.PetStatusSold}}, nil,
+		},
+		{"unknown status", "status=listed,gone", models.PetFilter{Statuses: []models.PetStatus{models.PetStatusListed}}, []string{"status"}},
+		{"vaccinated", "vaccinated=true", models.PetFilter{Vaccinated: &vaccinated}, nil},
+		{"vaccinated not a bool", "vaccinated=maybe", models.PetFilter{}, []string{"vaccinated"}},
+		{"include deleted", "include_deleted=1", models.PetFilter{IncludeDeleted: true}, nil},
+		{
+			"dates and timestamps", "created_after=2024-01-01&created_before=2024-02-01T12:00:00Z",
+			models.PetFilter{CreatedAfter: &after, CreatedBefore: &before}, nil,
+		},
+		{"bad date", "created_after=01/01/2024", models.PetFilter{}, []string{"created_after"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/pets?"+tt.query, nil)
+			got, verr := parsePetFilter(r)
+
+			var gotFields []string
+			if verr != nil {
+				for _, field := range tt.wantFields {
+					if verr.Fields[field] == "" {
+						t.Errorf("missing error for %s in %v", field, verr.Fields)
+					}
+				}
+				for field := range verr.Fields {
+					gotFields = append(gotFields, field)
+				}
+			}
+			if len(gotFields) != len(tt.wantFields) {
+				t.Fatalf("errors on %v, want %v", gotFields, tt.wantFields)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filter = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPetFilterValidate(t *testing.T) {
+	two, five := 2, 5
+	early := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	late := early.Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		filter    models.PetFilter
+		wantField string
+	}{
+		{"empty", models.PetFilter{}, ""},
+		{"price range", models.PetFilter{MinPrice: &models.Money{Amount: 100}, MaxPrice: &models.Money{Amount: 100}}, ""},
+		{"inverted price range", models.PetFilter{MinPrice: &models.Money{Amount: 200}, MaxPrice: &models.Money{Amount: 100}}, "max_price"},
+		{"inverted age range", models.PetFilter{MinAge: &five, MaxAge: &two}, "max_age"},
+		{"date range", models.PetFilter{CreatedAfter: &early, CreatedBefore: &late}, ""},
+		{"empty date range", models.PetFilter{CreatedAfter: &early, CreatedBefore: &early}, "created_before"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			verr, ok := err.(*models.ValidationError)
+			if !ok || verr.Fields[tt.wantField] == "" {
+				t.Errorf("Validate() = %v, want an error on %s", err, tt.wantField)
+			}
+		})
+	}
+}
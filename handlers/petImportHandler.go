@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"petstore-api/models"
+	"petstore-api/services"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// maxPetImportBytes caps the size of an import file.
+	maxPetImportBytes = 5 << 20
+	// maxPetImportLine caps a single NDJSON line.
+	maxPetImportLine = 64 << 10
+)
+
+// petImportColumns are the CSV columns an import may use. price is in major
+// units of currency, which defaults to USD.
+var petImportColumns = map[string]bool{
+	"name": true, "species": true, "breed": true, "age": true, "price": true,
+	"currency": true, "description": true, "status": true, "seller_id": true,
+}
+
+// petImportIgnoredColumns are the read-only columns of an export. They are
+// accepted so an export can be imported again, but every imported pet is
+// new, so their values are not used.
+var petImportIgnoredColumns = map[string]bool{
+	"id": true, "created_at": true, "updated_at": true,
+}
+
+// ImportPets godoc
+// @Summary Import pets from a file
+// @Description Create many pets for a seller from CSV (text/csv) or NDJSON (application/x-ndjson), up to 1000 rows. CSV needs a header row naming the columns: name, species, breed, age, price (major units, e.g. 19.99), currency, description, status, seller_id. The id, created_at and updated_at columns of an export are accepted and ignored, so an export can be imported as it is. Each NDJSON line is a pet in the POST /pets format. Every row is checked as POST /pets would check it; seller_id may be left out but must otherwise match the path. With dry_run nothing is stored and the report lists the rows that would fail. Otherwise valid rows are stored in batches in a single transaction: all_or_nothing stores nothing if any row fails, partial stores every row that succeeds. Only the seller themselves or an admin may do this
+// @Tags pets
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Param id path int true "Seller ID"
+// @Param dry_run query bool false "Only validate the rows"
+// @Param mode query string false "What to do with the valid rows when others fail (default all_or_nothing)" Enums(all_or_nothing,partial)
+// @Param file body string true "CSV or NDJSON rows"
+// @Success 200 {object} Response{data=models.PetImportReport} "Dry run"
+// @Success 201 {object} Response{data=models.PetImportReport}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 413 {object} Response
+// @Failure 415 {object} Response
+// @Failure 422 {object} Response{data=models.PetImportReport} "No pets were imported"
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /sellers/{id}/pets/import [post]
+func (h *PetHandler) ImportPets(w http.ResponseWriter, r *http.Request) {
+	sellerID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid seller ID")
+		return
+	}
+
+	var paramErr models.ValidationError
+	options := models.PetImportOptions{Mode: models.PetImportAllOrNothing}
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		options.DryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			paramErr.Add("dry_run", "must be true or false")
+		}
+	}
+	if value := r.URL.Query().Get("mode"); value != "" {
+		options.Mode = models.PetImportMode(value)
+		if !options.Mode.Valid() {
+			paramErr.Add("mode", "must be all_or_nothing or partial")
+		}
+	}
+	if paramErr.HasErrors() {
+		SendValidationErrorResponse(w, &paramErr)
+		return
+	}
+
+	var parse func(io.Reader) ([]models.PetImportRow, error)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		parse = parsePetCSV
+	case "application/x-ndjson", "application/ndjson":
+		parse = parsePetNDJSON
+	default:
+		SendErrorResponse(w, http.StatusUnsupportedMediaType, "Content-Type must be text/csv or application/x-ndjson")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxPetImportBytes)
+	rows, err := parse(r.Body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			SendErrorResponse(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("import must not be larger than %d bytes", maxPetImportBytes))
+			return
+		}
+		SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := h.service.ImportPets(r.Context(), uint(sellerID), rows, options)
+	if err != nil {
+		sendPetImportError(w, err)
+		return
+	}
+
+	switch {
+	case report.DryRun:
+		SendSuccessResponse(w, report, "Dry run completed, no pets were stored")
+	case report.Imported > 0:
+		SendCreatedResponse(w, report, fmt.Sprintf("Imported %d of %d pets", report.Imported, report.Rows))
+	default:
+		SendResponse(w, http.StatusUnprocessableEntity, Response{
+			Success: false,
+			Error:   "no pets were imported",
+			Data:    report,
+		})
+	}
+}
+
+func sendPetImportError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrForbidden):
+		SendErrorResponse(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrSellerNotFound):
+		SendErrorResponse(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrNotSeller),
+		err.Error() == "import contains no pets",
+		err.Error() == "mode must be all_or_nothing or partial",
+		strings.HasPrefix(err.Error(), "import must not contain more than"):
+		SendErrorResponse(w, http.StatusBadRequest, err.Error())
+	default:
+		SendErrorResponse(w, http.StatusInternalServerError, "Failed to import pets")
+	}
+}
+
+// parsePetCSV reads a CSV file whose first record names the columns. Rows
+// with bad values are returned with their errors so they show up in the
+// report; only a malformed file is an error.
+func parsePetCSV(body io.Reader) ([]models.PetImportRow, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, csvError(err)
+	}
+
+	columns := make([]string, len(header))
+	seen := make(map[string]bool)
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		if !petImportColumns[column] && !petImportIgnoredColumns[column] {
+			return nil, fmt.Errorf("unknown CSV column %q", column)
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("CSV column %q appears more than once", column)
+		}
+		seen[column] = true
+		columns[i] = column
+	}
+	if !seen["name"] || !seen["species"] {
+		return nil, errors.New("CSV header must include name and species")
+	}
+
+	var rows []models.PetImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, csvError(err)
+		}
+		if len(rows) == models.MaxPetImportRows {
+			return nil, fmt.Errorf("import must not contain more than %d pets", models.MaxPetImportRows)
+		}
+
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, petCSVRow(line, columns, record))
+	}
+
+	return rows, nil
+}
+
+func petCSVRow(line int, columns []string, record []string) models.PetImportRow {
+	row := models.PetImportRow{Line: line, Errors: &models.ValidationError{}}
+	if len(record) != len(columns) {
+		row.Errors.Add("row", fmt.Sprintf("has %d fields but the header has %d", len(record), len(columns)))
+		return row
+	}
+
+	values := make(map[string]string, len(columns))
+	for i, column := range columns {
+		values[column] = strings.TrimSpace(record[i])
+	}
+
+	pet := &row.Pet
+	pet.Name = values["name"]
+	pet.Species = values["species"]
+	pet.Breed = values["breed"]
+	pet.Description = values["description"]
+	pet.Status = models.PetStatus(values["status"])
+
+	if value := values["age"]; value != "" {
+		age, err := strconv.Atoi(value)
+		if err != nil {
+			row.Errors.Add("age", "must be a whole number")
+		}
+		pet.Age = age
+	}
+	if value := values["seller_id"]; value != "" {
+		sellerID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			row.Errors.Add("seller_id", "must be a user ID")
+		}
+		pet.SellerID = uint(sellerID)
+	}
+	pet.Price.Currency = values["currency"]
+	if value := values["price"]; value != "" {
+		price, err := models.ParseMoney(value, values["currency"])
+		if err != nil {
+			row.Errors.Add("price", err.Error())
+		}
+		pet.Price = price
+	}
+
+	return row
+}
+
+func csvError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) && parseErr.Err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(parseErr.Err, &tooLarge) {
+			return parseErr.Err
+		}
+		return fmt.Errorf("invalid CSV on line %d: %v", parseErr.StartLine, parseErr.Err)
+	}
+	return err
+}
+
+// parsePetNDJSON reads one pet per line in the POST /pets format. Blank
+// lines are skipped and a line that is not a pet is reported as a failed
+// row.
+func parsePetNDJSON(body io.Reader) ([]models.PetImportRow, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 4096), maxPetImportLine)
+
+	var rows []models.PetImportRow
+	for line := 1; scanner.Scan(); line++ {
+		data := strings.TrimSpace(scanner.Text())
+		if data == "" {
+			continue
+		}
+		if len(rows) == models.MaxPetImportRows {
+			return nil, fmt.Errorf("import must not contain more than %d pets", models.MaxPetImportRows)
+		}
+
+		row := models.PetImportRow{Line: line, Errors: &models.ValidationError{}}
+		if err := json.Unmarshal([]byte(data), &row.Pet); err != nil {
+			row.Errors.Add("row", "is not a valid JSON pet")
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("NDJSON lines must not be longer than %d bytes", maxPetImportLine)
+		}
+		return nil, err
+	}
+
+	return rows, nil
+}
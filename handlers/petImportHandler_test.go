@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"petstore-api/models"
+)
+
+func TestParsePetCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    []models.PetImportRow
+		wantErr string
+	}{
+		{"empty body", "", nil, ""},
+		{"header only", "name,species\n", nil, ""},
+		{
+			"all columns", "name,species,breed,age,price,currency,description,status,seller_id\nRex,dog,beagle,3,19.99,usd,Friendly,listed,7\n",
+			[]models.PetImportRow{{Line: 2, Pet: models.CreatePetRequest{
+				Name: "Rex", Species: "dog", Breed: "beagle", Age: 3, Price: models.Money{Amount: 1999, Currency: "USD"},
+				Description: "Friendly", Status: models.PetStatusListed, SellerID: 7,
+			}}}, "",
+		},
+		{
+			"byte order mark and header case", "\ufeffName, SPECIES\nRex,dog\n",
+			[]models.PetImportRow{{Line: 2, Pet: models.CreatePetRequest{Name: "Rex", Species: "dog"}}}, "",
+		},
+		{
+			"export columns are ignored", "id,name,species,created_at,updated_at\n12,Rex,dog,2024-01-01T00:00:00Z,2024-01-02T00:00:00Z\n",
+			[]models.PetImportRow{{Line: 2, Pet: models.CreatePetRequest{Name: "Rex", Species: "dog"}}}, "",
+		},
+		{
+			"quoted field over several lines", "name,species,description\nRex,dog,\"line one\nline two\"\nTom,cat,\n",
+			[]models.PetImportRow{
+				{Line: 2, Pet: models.CreatePetRequest{Name: "Rex", Species: "dog", Description: "line one\nline two"}},
+				{Line: 4, Pet: models.CreatePetRequest{Name: "Tom", Species: "cat"}},
+			}, "",
+		},
+		{"unknown column", "name,species,colour\n", nil, `unknown CSV column "colour"`},
+		{"duplicate column", "name,species,Name\n", nil, `CSV column "name" appears more than once`},
+		{"missing species", "name,breed\n", nil, "CSV header must include name and species"},
+		{"bare quote", "name,species\nRex,d\"og\n", nil, "invalid CSV on line 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := parsePetCSV(strings.NewReader(tt.body))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parsePetCSV error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePetCSV: %v", err)
+			}
+			if len(rows) != len(tt.want) {
+				t.Fatalf("parsePetCSV returned %d rows, want %d", len(rows), len(tt.want))
+			}
+			for i, row := range rows {
+				if row.Errors.HasErrors() {
+					t.Errorf("row %d has errors %v", i, row.Errors.Fields)
+				}
+				if row.Line != tt.want[i].Line || !reflect.DeepEqual(row.Pet, tt.want[i].Pet) {
+					t.Errorf("row %d = line %d %+v, want line %d %+v", i, row.Line, row.Pet, tt.want[i].Line, tt.want[i].Pet)
+				}
+			}
+		})
+	}
+}
+
+func TestParsePetCSVRowErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		row        string
+		wantFields []string
+	}{
+		{"too few fields", "Rex,dog", []string{"row"}},
+		{"too many fields", "Rex,dog,3,19.99,extra", []string{"row"}},
+		{"age not a number", "Rex,dog,three,19.99", []string{"age"}},
+		{"bad price and age", "Rex,dog,3.5,1.999", []string{"age", "price"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := parsePetCSV(strings.NewReader("name,species,age,price\n" + tt.row + "\n"))
+			if err != nil {
+				t.Fatalf("parsePetCSV: %v", err)
+			}
+			if len(rows) != 1 {
+				t.Fatalf("parsePetCSV returned %d rows, want 1", len(rows))
+			}
+			fields := rows[0].Errors.Fields
+			if len(fields) != len(tt.wantFields) {
+				t.Errorf("errors = %v, want errors on %v", fields, tt.wantFields)
+			}
+			for _, field := range tt.wantFields {
+				if fields[field] == "" {
+					t.Errorf("missing error for %s in %v", field, fields)
+				}
+			}
+		})
+	}
+}
+
+func TestParsePetImportRowCap(t *testing.T) {
+	csvBody := func(n int) string {
+		var b strings.Builder
+		b.WriteString("name,species\n")
+		for i := 0; i < n; i++ {
+			fmt.Fprintf(&b, "Pet %d,dog\n", i)
+		}
+		return b.String()
+	}
+	ndjsonBody := func(n int) string {
+		var b strings.Builder
+		for i := 0; i < n; i++ {
+			fmt.Fprintf(&b, "{\"name\":\"Pet %d\",\"species\":\"dog\"}\n\n", i)
+		}
+		return b.String()
+	}
+
+	parsers := []struct {
+		name  string
+		parse func(string) ([]models.PetImportRow, error)
+		body  func(int) string
+	}{
+		{"csv", func(s string) ([]models.PetImportRow, error) { return parsePetCSV(strings.NewReader(s)) }, csvBody},
+		{"ndjson", func(s string) ([]models.PetImportRow, error) { return parsePetNDJSON(strings.NewReader(s)) }, ndjsonBody},
+	}
+
+	for _, p := range parsers {
+		t.Run(p.name, func(t *testing.T) {
+			rows, err := p.parse(p.body(models.MaxPetImportRows))
+			if err != nil || len(rows) != models.MaxPetImportRows {
+				t.Errorf("%d rows: got %d rows, %v", models.MaxPetImportRows, len(rows), err)
+			}
+			if _, err := p.parse(p.body(models.MaxPetImportRows + 1)); err == nil {
+				t.Errorf("%d rows: want an error", models.MaxPetImportRows+1)
+			}
+		})
+	}
+}
+
+func TestParsePetNDJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantLines []int
+		wantBad   []int
+		wantErr   bool
+	}{
+		{"empty body", "", nil, nil, false},
+		{"blank lines are skipped", "\n{\"name\":\"Rex\",\"species\":\"dog\"}\n  \n{\"name\":\"Tom\",\"species\":\"cat\"}", []int{2, 4}, nil, false},
+		{"CRLF line endings", "{\"name\":\"Rex\",\"species\":\"dog\"}\r\n{\"name\":\"Tom\",\"species\":\"cat\"}\r\n", []int{1, 2}, nil, false},
+		{"line that is not a pet", "{\"name\":\"Rex\",\"species\":\"dog\"}\nnot json\n[1,2]\n", []int{1, 2, 3}, []int{2, 3}, false},
+		{"line just under the limit", `{"name":"` + strings.Repeat("a", maxPetImportLine-1-len(`{"name":""}`)) + "\"}\n", []int{1}, nil, false},
+		{"overlong line", `{"name":"` + strings.Repeat("a", maxPetImportLine) + `"}`, nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := parsePetNDJSON(strings.NewReader(tt.body))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("parsePetNDJSON: want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePetNDJSON: %v", err)
+			}
+
+			var lines, bad []int
+			for _, row := range rows {
+				lines = append(lines, row.Line)
+				if row.Errors.HasErrors() {
+					bad = append(bad, row.Line)
+				}
+			}
+			if !reflect.DeepEqual(lines, tt.wantLines) {
+				t.Errorf("lines = %v, want %v", lines, tt.wantLines)
+			}
+			if !reflect.DeepEqual(bad, tt.wantBad) {
+				t.Errorf("failed lines = %v, want %v", bad, tt.wantBad)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"petstore-api/media"
+	"petstore-api/models"
+	"petstore-api/services"
+
+	"github.com/gorilla/mux"
+)
+
+// multipartOverhead allows for the multipart headers and boundaries around
+// an upload of the maximum size.
+const multipartOverhead = 64 << 10
+
+type PhotoHandler struct {
+	service  services.PhotoService
+	maxBytes int64
+}
+
+func NewPhotoHandler(service services.PhotoService, maxBytes int64) *PhotoHandler {
+	return &PhotoHandler{service: service, maxBytes: maxBytes}
+}
+
+// UploadPhoto godoc
+// @Summary Upload a pet photo
+// @Description Upload a JPEG, PNG or GIF photo as the multipart field "photo". The type is detected from the file contents. A thumbnail is generated, and the first photo of a pet becomes its primary photo. Only the owning seller or an admin may do this
+// @Tags photos
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Pet ID"
+// @Param photo formData file true "Image file"
+// @Success 201 {object} Response{data=models.PetPhoto}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 413 {object} Response
+// @Failure 415 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /pets/{id}/photos [post]
+func (h *PhotoHandler) UploadPhoto(w http.ResponseWriter, r *http.Request) {
+	petID, ok := parsePetID(w, r)
+	if !ok {
+		return
+	}
+
+	data, status, err := h.readUpload(w, r)
+	if err != nil {
+		SendErrorResponse(w, status, err.Error())
+		return
+	}
+
+	photo, err := h.service.AddPhoto(r.Context(), petID, data)
+	if err != nil {
+		sendPhotoError(w, err)
+		return
+	}
+
+	SendCreatedResponse(w, photo, "Photo uploaded successfully")
+}
+
+// GetPhotos godoc
+// @Summary Get pet photos
+// @Description Get a pet's photos in display order. The photos of drafts and withdrawn pets are only found by their seller and admins
+// @Tags photos
+// @Accept json
+// @Produce json
+// @Param id path int true "Pet ID"
+// @Success 200 {object} Response{data=[]models.PetPhoto}
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Router /pets/{id}/photos [get]
+func (h *PhotoHandler) GetPhotos(w http.ResponseWriter, r *http.Request) {
+	petID, ok := parsePetID(w, r)
+	if !ok {
+		return
+	}
+
+	photos, err := h.service.GetPhotos(r.Context(), petID)
+	if err != nil {
+		sendPhotoError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, photos, "")
+}
+
+// ReorderPhotos godoc
+// @Summary Reorder pet photos
+// @Description Set the display order of a pet's photos. photo_ids must list every photo of the pet exactly once
+// @Tags photos
+// @Accept json
+// @Produce json
+// @Param id path int true "Pet ID"
+// @Param order body models.ReorderPhotosRequest true "Photo IDs in the new order"
+// @Success 200 {object} Response{data=[]models.PetPhoto}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /pets/{id}/photos/order [put]
+func (h *PhotoHandler) ReorderPhotos(w http.ResponseWriter, r *http.Request) {
+	petID, ok := parsePetID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ReorderPhotosRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	photos, err := h.service.ReorderPhotos(r.Context(), petID, req.PhotoIDs)
+	if err != nil {
+		sendPhotoError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, photos, "Photos reordered successfully")
+}
+
+// SetPrimaryPhoto godoc
+// @Summary Set the primary pet photo
+// @Description Make a photo the pet's primary photo
+// @Tags photos
+// @Accept json
+// @Produce json
+// @Param id path int true "Pet ID"
+// @Param photoId path int true "Photo ID"
+// @Success 200 {object} Response{data=[]models.PetPhoto}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /pets/{id}/photos/{photoId}/primary [put]
+func (h *PhotoHandler) SetPrimaryPhoto(w http.ResponseWriter, r *http.Request) {
+	petID, ok := parsePetID(w, r)
+	if !ok {
+		return
+	}
+	photoID, err := strconv.Atoi(mux.Vars(r)["photoId"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid photo ID")
+		return
+	}
+
+	photos, err := h.service.SetPrimaryPhoto(r.Context(), petID, uint(photoID))
+	if err != nil {
+		sendPhotoError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, photos, "Primary photo updated successfully")
+}
+
+// DeletePhoto godoc
+// @Summary Delete a pet photo
+// @Description Delete a photo and its thumbnail. If it was the primary photo, the next photo in order becomes primary
+// @Tags photos
+// @Accept json
+// @Produce json
+// @Param id path int true "Pet ID"
+// @Param photoId path int true "Photo ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /pets/{id}/photos/{photoId} [delete]
+func (h *PhotoHandler) DeletePhoto(w http.ResponseWriter, r *http.Request) {
+	petID, ok := parsePetID(w, r)
+	if !ok {
+		return
+	}
+	photoID, err := strconv.Atoi(mux.Vars(r)["photoId"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid photo ID")
+		return
+	}
+
+	if err := h.service.DeletePhoto(r.Context(), petID, uint(photoID)); err != nil {
+		sendPhotoError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, nil, "Photo deleted successfully")
+}
+
+// readUpload returns the contents of the "photo" part of a multipart body.
+// Parts are streamed, so nothing beyond maxBytes is ever buffered.
+func (h *PhotoHandler) readUpload(w http.ResponseWriter, r *http.Request) ([]byte, int, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxBytes+multipartOverhead)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.New("request must be multipart/form-data")
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, http.StatusBadRequest, errors.New("multipart field \"photo\" is required")
+		}
+		if err != nil {
+			return nil, uploadErrorStatus(err), errors.New("invalid multipart body")
+		}
+		if part.FormName() != "photo" {
+			continue
+		}
+
+		data, err := io.ReadAll(io.LimitReader(part, h.maxBytes+1))
+		if err != nil {
+			return nil, uploadErrorStatus(err), errors.New("failed to read upload")
+		}
+		if int64(len(data)) > h.maxBytes {
+			return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("photo must not be larger than %d bytes", h.maxBytes)
+		}
+		if len(data) == 0 {
+			return nil, http.StatusBadRequest, errors.New("photo is empty")
+		}
+		return data, 0, nil
+	}
+}
+
+func uploadErrorStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+func parsePetID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid pet ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func sendPhotoError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrForbidden):
+		SendErrorResponse(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, media.ErrUnsupportedImage):
+		SendErrorResponse(w, http.StatusUnsupportedMediaType, err.Error())
+	case errors.Is(err, media.ErrImageTooLarge):
+		SendErrorResponse(w, http.StatusBadRequest, err.Error())
+	case err.Error() == "pet not found", err.Error() == "photo not found":
+		SendErrorResponse(w, http.StatusNotFound, err.Error())
+	case err.Error() == "photo_ids must list every photo of the pet exactly once":
+		SendErrorResponse(w, http.StatusBadRequest, err.Error())
+	default:
+		SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+	}
+}
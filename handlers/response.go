@@ -3,13 +3,17 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+
+	"petstore-api/models"
 )
 
 type Response struct {
-	Success bool        `json:"success"`
-	Message string      `json:"message,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	Success    bool              `json:"success"`
+	Message    string            `json:"message,omitempty"`
+	Data       interface{}       `json:"data,omitempty"`
+	Pagination *models.PageInfo  `json:"pagination,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	Fields     map[string]string `json:"fields,omitempty"`
 }
 
 func SendResponse(w http.ResponseWriter, statusCode int, response Response) {
@@ -26,6 +30,17 @@ func SendSuccessResponse(w http.ResponseWriter, data interface{}, message string
 	})
 }
 
+// SendPageResponse sends one page of a list together with the cursor for
+// the next page.
+func SendPageResponse(w http.ResponseWriter, data interface{}, page *models.PageInfo, message string) {
+	SendResponse(w, http.StatusOK, Response{
+		Success:    true,
+		Message:    message,
+		Data:       data,
+		Pagination: page,
+	})
+}
+
 func SendCreatedResponse(w http.ResponseWriter, data interface{}, message string) {
 	SendResponse(w, http.StatusCreated, Response{
 		Success: true,
@@ -40,3 +55,13 @@ func SendErrorResponse(w http.ResponseWriter, statusCode int, errorMsg string) {
 		Error:   errorMsg,
 	})
 }
+
+// SendValidationErrorResponse reports a 400 with a message for each invalid
+// field.
+func SendValidationErrorResponse(w http.ResponseWriter, err *models.ValidationError) {
+	SendResponse(w, http.StatusBadRequest, Response{
+		Success: false,
+		Error:   err.Error(),
+		Fields:  err.Fields,
+	})
+}
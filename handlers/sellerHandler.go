@@ -2,20 +2,22 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"petstore-api/models"
+	"petstore-api/repositories"
 	"petstore-api/services"
 
 	"github.com/gorilla/mux"
 )
 
 type SellerHandler struct {
-	service services.SellerService
+	service services.UserService
 }
 
-func NewSellerHandler(service services.SellerService) *SellerHandler {
+func NewSellerHandler(service services.UserService) *SellerHandler {
 	return &SellerHandler{service: service}
 }
 
@@ -26,19 +28,39 @@ func NewSellerHandler(service services.SellerService) *SellerHandler {
 // @Accept json
 // @Produce json
 // @Param include_pets query bool false "Include pets in response"
-// @Success 200 {object} Response{data=[]models.Seller}
+// @Param include_deleted query bool false "Also include deleted sellers that have not been purged yet (admins only)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param cursor query string false "Cursor from pagination.next_cursor of the previous page"
+// @Success 200 {object} Response{data=[]models.User}
+// @Failure 400 {object} Response
+// @Failure 403 {object} Response
 // @Failure 500 {object} Response
 // @Router /sellers [get]
 func (h *SellerHandler) GetSellers(w http.ResponseWriter, r *http.Request) {
 	includePets := r.URL.Query().Get("include_pets") == "true"
+	includeDeleted := r.URL.Query().Get("include_deleted") == "true"
 
-	sellers, err := h.service.GetAllSellers(includePets)
+	page, err := parsePageRequest(r)
 	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sellers, info, err := h.service.GetAll(r.Context(), includePets, includeDeleted, page)
+	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, models.ErrInvalidCursor) {
+			SendErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		SendErrorResponse(w, http.StatusInternalServerError, "Failed to fetch sellers")
 		return
 	}
 
-	SendSuccessResponse(w, sellers, "")
+	SendPageResponse(w, sellers, info, "")
 }
 
 // GetSeller godoc
@@ -49,8 +71,11 @@ func (h *SellerHandler) GetSellers(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path int true "Seller ID"
 // @Param include_pets query bool false "Include pets in response"
-// @Success 200 {object} Response{data=models.Seller}
+// @Param include_deleted query bool false "Also find the seller if they were deleted and have not been purged yet (admins only)"
+// @Success 200 {object} Response{data=models.User}
+// @Header 200 {string} ETag "Version of the seller, for If-Match on PUT /sellers/{id}"
 // @Failure 400 {object} Response
+// @Failure 403 {object} Response
 // @Failure 404 {object} Response
 // @Failure 500 {object} Response
 // @Router /sellers/{id} [get]
@@ -63,9 +88,14 @@ func (h *SellerHandler) GetSeller(w http.ResponseWriter, r *http.Request) {
 	}
 
 	includePets := r.URL.Query().Get("include_pets") == "true"
+	includeDeleted := r.URL.Query().Get("include_deleted") == "true"
 
-	seller, err := h.service.GetSellerByID(uint(id), includePets)
+	seller, err := h.service.GetByID(r.Context(), uint(id), includePets, includeDeleted)
 	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
 		if err.Error() == "seller not found" {
 			SendErrorResponse(w, http.StatusNotFound, err.Error())
 			return
@@ -74,21 +104,25 @@ func (h *SellerHandler) GetSeller(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setETag(w, seller.Version)
 	SendSuccessResponse(w, seller, "")
 }
 
 // CreateSeller godoc
 // @Summary Create a new seller
-// @Description Create a new seller with the provided information
+// @Description Create a new seller account without a password (admin only). Users normally sign up through /auth/register
 // @Tags sellers
 // @Accept json
 // @Produce json
-// @Param seller body models.CreateSellerRequest true "Seller creation data"
-// @Success 201 {object} Response{data=models.Seller}
+// @Param seller body models.CreateUserRequest true "Seller creation data"
+// @Success 201 {object} Response{data=models.User}
 // @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Security BearerAuth
 // @Router /sellers [post]
 func (h *SellerHandler) CreateSeller(w http.ResponseWriter, r *http.Request) {
-	var req models.CreateSellerRequest
+	var req models.CreateUserRequest
 
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
@@ -96,7 +130,7 @@ func (h *SellerHandler) CreateSeller(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	seller, err := h.service.CreateSeller(&req)
+	seller, err := h.service.Create(r.Context(), &req)
 	if err != nil {
 		SendErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
@@ -107,16 +141,23 @@ func (h *SellerHandler) CreateSeller(w http.ResponseWriter, r *http.Request) {
 
 // UpdateSeller godoc
 // @Summary Update seller
-// @Description Update an existing seller's information
+// @Description Update an existing seller's information. Only the seller themselves or an admin may do this. Send the ETag of the seller as If-Match to make sure nobody else has changed it since; an update that loses against a concurrent change fails with 409 instead of overwriting it, as does an email another user already has
 // @Tags sellers
 // @Accept json
 // @Produce json
 // @Param id path int true "Seller ID"
-// @Param seller body models.UpdateSellerRequest true "Seller update data"
-// @Success 200 {object} Response{data=models.Seller}
+// @Param If-Match header string false "ETag of the seller as last read"
+// @Param seller body models.UpdateUserRequest true "Seller update data"
+// @Success 200 {object} Response{data=models.User}
+// @Header 200 {string} ETag "New version of the seller"
 // @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
 // @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Failure 412 {object} Response
 // @Failure 500 {object} Response
+// @Security BearerAuth
 // @Router /sellers/{id} [put]
 func (h *SellerHandler) UpdateSeller(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -126,38 +167,54 @@ func (h *SellerHandler) UpdateSeller(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req models.UpdateSellerRequest
+	var req models.UpdateUserRequest
 	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
 		SendErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
 		return
 	}
 
-	seller, err := h.service.UpdateSeller(uint(id), &req)
+	seller, err := h.service.Update(r.Context(), uint(id), &req, parseIfMatch(r))
 	if err != nil {
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			sendVersionConflict(w, r, err)
+			return
+		}
+		if errors.Is(err, services.ErrForbidden) {
+			SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
 		if err.Error() == "seller not found" {
 			SendErrorResponse(w, http.StatusNotFound, err.Error())
 			return
 		}
+		if errors.Is(err, repositories.ErrEmailTaken) {
+			SendErrorResponse(w, http.StatusConflict, err.Error())
+			return
+		}
 		SendErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	setETag(w, seller.Version)
 	SendSuccessResponse(w, seller, "Seller updated successfully")
 }
 
 // DeleteSeller godoc
 // @Summary Delete seller
-// @Description Delete a seller by ID (only if no pets are associated)
+// @Description Delete a seller by ID (only if no pets are associated). Deleted sellers can be restored by an admin until they are purged after the retention period
 // @Tags sellers
 // @Accept json
 // @Produce json
 // @Param id path int true "Seller ID"
 // @Success 200 {object} Response
 // @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
 // @Failure 404 {object} Response
 // @Failure 409 {object} Response
 // @Failure 500 {object} Response
+// @Security BearerAuth
 // @Router /sellers/{id} [delete]
 func (h *SellerHandler) DeleteSeller(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -167,8 +224,12 @@ func (h *SellerHandler) DeleteSeller(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.DeleteSeller(uint(id))
+	err = h.service.Delete(r.Context(), uint(id))
 	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
 		if err.Error() == "seller not found" {
 			SendErrorResponse(w, http.StatusNotFound, err.Error())
 			return
@@ -183,3 +244,141 @@ func (h *SellerHandler) DeleteSeller(w http.ResponseWriter, r *http.Request) {
 
 	SendSuccessResponse(w, nil, "Seller deleted successfully")
 }
+
+// RestoreSeller godoc
+// @Summary Restore a deleted seller
+// @Description Bring back a deleted seller that has not been purged yet, with the roles they had. Fails if another user has taken the email since. Admin only
+// @Tags sellers
+// @Accept json
+// @Produce json
+// @Param id path int true "Seller ID"
+// @Success 200 {object} Response{data=models.User}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /sellers/{id}/restore [post]
+func (h *SellerHandler) RestoreSeller(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid seller ID")
+		return
+	}
+
+	seller, err := h.service.Restore(r.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if err.Error() == "seller not found" {
+			SendErrorResponse(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err.Error() == "user is not deleted" || errors.Is(err, repositories.ErrEmailTaken) {
+			SendErrorResponse(w, http.StatusConflict, err.Error())
+			return
+		}
+		SendErrorResponse(w, http.StatusInternalServerError, "Failed to restore seller")
+		return
+	}
+
+	SendSuccessResponse(w, seller, "Seller restored successfully")
+}
+
+// GetSellerVersions godoc
+// @Summary Get a seller's version history
+// @Description Get every recorded create, update, delete and restore of a seller's user record, oldest first, with who made it and the fields it changed. Changes made as a buyer are included, since both share the record. Only the seller themselves or an admin may do this
+// @Tags sellers
+// @Accept json
+// @Produce json
+// @Param id path int true "Seller ID"
+// @Success 200 {object} Response{data=[]models.AuditEntry}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /sellers/{id}/versions [get]
+func (h *SellerHandler) GetSellerVersions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid seller ID")
+		return
+	}
+
+	versions, err := h.service.GetVersions(r.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
+		SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	SendSuccessResponse(w, versions, "")
+}
+
+// RestoreSellerVersion godoc
+// @Summary Restore a seller version
+// @Description Put back the name, email, phone and address a seller had in an earlier version. Roles are not restored, and a deleted seller has to be brought back with POST /sellers/{id}/restore first. Restoring an email another user has taken since fails with 409. Only the seller themselves or an admin may do this. Send the ETag of the seller as If-Match to make sure nobody else has changed it since
+// @Tags sellers
+// @Accept json
+// @Produce json
+// @Param id path int true "Seller ID"
+// @Param version path int true "Version to restore"
+// @Param If-Match header string false "ETag of the seller as last read"
+// @Success 200 {object} Response{data=models.User}
+// @Header 200 {string} ETag "New version of the seller"
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Failure 412 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /sellers/{id}/versions/{version}/restore [post]
+func (h *SellerHandler) RestoreSellerVersion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid seller ID")
+		return
+	}
+	version, ok := parseVersion(w, r)
+	if !ok {
+		return
+	}
+
+	seller, err := h.service.RestoreVersion(r.Context(), uint(id), version, parseIfMatch(r))
+	if err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			SendErrorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if err.Error() == "seller not found" || err.Error() == "version not found" {
+			SendErrorResponse(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			sendVersionConflict(w, r, err)
+			return
+		}
+		if errors.Is(err, repositories.ErrEmailTaken) {
+			SendErrorResponse(w, http.StatusConflict, err.Error())
+			return
+		}
+		SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	setETag(w, seller.Version)
+	SendSuccessResponse(w, seller, "Seller version restored successfully")
+}
@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"petstore-api/models"
+	"petstore-api/repositories"
+	"petstore-api/services"
+
+	"github.com/gorilla/mux"
+)
+
+type TaxonomyHandler struct {
+	service services.TaxonomyService
+}
+
+func NewTaxonomyHandler(service services.TaxonomyService) *TaxonomyHandler {
+	return &TaxonomyHandler{service: service}
+}
+
+// GetAllSpecies godoc
+// @Summary Get all species
+// @Description Get the known species with their aliases and breeds. Pets can only be listed under these
+// @Tags taxonomy
+// @Accept json
+// @Produce json
+// @Success 200 {object} Response{data=[]models.Species}
+// @Failure 500 {object} Response
+// @Router /species [get]
+func (h *TaxonomyHandler) GetAllSpecies(w http.ResponseWriter, r *http.Request) {
+	species, err := h.service.GetAllSpecies()
+	if err != nil {
+		SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	SendSuccessResponse(w, species, "")
+}
+
+// GetSpecies godoc
+// @Summary Get species by ID
+// @Description Get a species with its aliases and breeds
+// @Tags taxonomy
+// @Accept json
+// @Produce json
+// @Param id path int true "Species ID"
+// @Success 200 {object} Response{data=models.Species}
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Router /species/{id} [get]
+func (h *TaxonomyHandler) GetSpecies(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseTaxonomyID(w, r, "Invalid species ID")
+	if !ok {
+		return
+	}
+
+	species, err := h.service.GetSpecies(id)
+	if err != nil {
+		sendTaxonomyError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, species, "")
+}
+
+// CreateSpecies godoc
+// @Summary Create a species
+// @Description Add a species with optional aliases (admin only). Names and aliases are matched ignoring case and must not already refer to another species
+// @Tags taxonomy
+// @Accept json
+// @Produce json
+// @Param species body models.TaxonomyRequest true "Species"
+// @Success 201 {object} Response{data=models.Species}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /species [post]
+func (h *TaxonomyHandler) CreateSpecies(w http.ResponseWriter, r *http.Request) {
+	var req models.TaxonomyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	species, err := h.service.CreateSpecies(&req)
+	if err != nil {
+		sendTaxonomyError(w, err)
+		return
+	}
+
+	SendCreatedResponse(w, species, "Species created successfully")
+}
+
+// UpdateSpecies godoc
+// @Summary Update a species
+// @Description Rename a species and replace its aliases (admin only). Pets listed under the old name are renamed too
+// @Tags taxonomy
+// @Accept json
+// @Produce json
+// @Param id path int true "Species ID"
+// @Param species body models.TaxonomyRequest true "Species"
+// @Success 200 {object} Response{data=models.Species}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /species/{id} [put]
+func (h *TaxonomyHandler) UpdateSpecies(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseTaxonomyID(w, r, "Invalid species ID")
+	if !ok {
+		return
+	}
+
+	var req models.TaxonomyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	species, err := h.service.UpdateSpecies(id, &req)
+	if err != nil {
+		sendTaxonomyError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, species, "Species updated successfully")
+}
+
+// DeleteSpecies godoc
+// @Summary Delete a species
+// @Description Delete a species with its aliases and breeds (admin only). A species that pets still have cannot be deleted; move the pets to another species or delete them first
+// @Tags taxonomy
+// @Accept json
+// @Produce json
+// @Param id path int true "Species ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /species/{id} [delete]
+func (h *TaxonomyHandler) DeleteSpecies(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseTaxonomyID(w, r, "Invalid species ID")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteSpecies(id); err != nil {
+		sendTaxonomyError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, nil, "Species deleted successfully")
+}
+
+// GetBreeds godoc
+// @Summary Get the breeds of a species
+// @Description Get the breeds of a species with their aliases
+// @Tags taxonomy
+// @Accept json
+// @Produce json
+// @Param id path int true "Species ID"
+// @Success 200 {object} Response{data=[]models.Breed}
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Router /species/{id}/breeds [get]
+func (h *TaxonomyHandler) GetBreeds(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseTaxonomyID(w, r, "Invalid species ID")
+	if !ok {
+		return
+	}
+
+	breeds, err := h.service.GetBreeds(id)
+	if err != nil {
+		sendTaxonomyError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, breeds, "")
+}
+
+// CreateBreed godoc
+// @Summary Create a breed
+// @Description Add a breed to a species with optional aliases (admin only). Names and aliases must not already refer to another breed of the species
+// @Tags taxonomy
+// @Accept json
+// @Produce json
+// @Param id path int true "Species ID"
+// @Param breed body models.TaxonomyRequest true "Breed"
+// @Success 201 {object} Response{data=models.Breed}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /species/{id}/breeds [post]
+func (h *TaxonomyHandler) CreateBreed(w http.ResponseWriter, r *http.Request) {
+	speciesID, ok := parseTaxonomyID(w, r, "Invalid species ID")
+	if !ok {
+		return
+	}
+
+	var req models.TaxonomyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	breed, err := h.service.CreateBreed(speciesID, &req)
+	if err != nil {
+		sendTaxonomyError(w, err)
+		return
+	}
+
+	SendCreatedResponse(w, breed, "Breed created successfully")
+}
+
+// UpdateBreed godoc
+// @Summary Update a breed
+// @Description Rename a breed and replace its aliases (admin only). Pets of the species listed under the old name are renamed too
+// @Tags taxonomy
+// @Accept json
+// @Produce json
+// @Param id path int true "Breed ID"
+// @Param breed body models.TaxonomyRequest true "Breed"
+// @Success 200 {object} Response{data=models.Breed}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /breeds/{id} [put]
+func (h *TaxonomyHandler) UpdateBreed(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseTaxonomyID(w, r, "Invalid breed ID")
+	if !ok {
+		return
+	}
+
+	var req models.TaxonomyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	breed, err := h.service.UpdateBreed(id, &req)
+	if err != nil {
+		sendTaxonomyError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, breed, "Breed updated successfully")
+}
+
+// DeleteBreed godoc
+// @Summary Delete a breed
+// @Description Delete a breed with its aliases (admin only). Existing pets keep their breed name
+// @Tags taxonomy
+// @Accept json
+// @Produce json
+// @Param id path int true "Breed ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /breeds/{id} [delete]
+func (h *TaxonomyHandler) DeleteBreed(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseTaxonomyID(w, r, "Invalid breed ID")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteBreed(id); err != nil {
+		sendTaxonomyError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, nil, "Breed deleted successfully")
+}
+
+func parseTaxonomyID(w http.ResponseWriter, r *http.Request, message string) (uint, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, message)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func sendTaxonomyError(w http.ResponseWriter, err error) {
+	var verr *models.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		SendValidationErrorResponse(w, verr)
+	case err.Error() == "species not found", err.Error() == "breed not found":
+		SendErrorResponse(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, repositories.ErrSpeciesInUse):
+		SendErrorResponse(w, http.StatusConflict, err.Error())
+	default:
+		SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"petstore-api/models"
+	"petstore-api/services"
+
+	"github.com/gorilla/mux"
+)
+
+type UserHandler struct {
+	roles services.RoleService
+}
+
+func NewUserHandler(roles services.RoleService) *UserHandler {
+	return &UserHandler{roles: roles}
+}
+
+// GrantRole godoc
+// @Summary Grant a role to a user
+// @Description Give a user the seller, buyer or admin role. Users may grant themselves the seller or buyer role; everything else needs an admin. Granting a role the user already has is a no-op
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param role path string true "Role" Enums(seller, buyer, admin)
+// @Success 200 {object} Response{data=models.User}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /users/{id}/roles/{role} [put]
+func (h *UserHandler) GrantRole(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	user, err := h.roles.GrantRole(r.Context(), uint(id), models.Role(vars["role"]))
+	if err != nil {
+		sendRoleError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, user, "Role granted successfully")
+}
+
+// RevokeRole godoc
+// @Summary Revoke a role from a user
+// @Description Take a role away from a user. A user keeps at least one role, and the seller role cannot be revoked while the user still has pets
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param role path string true "Role" Enums(seller, buyer, admin)
+// @Success 200 {object} Response{data=models.User}
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Failure 500 {object} Response
+// @Security BearerAuth
+// @Router /users/{id}/roles/{role} [delete]
+func (h *UserHandler) RevokeRole(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	user, err := h.roles.RevokeRole(r.Context(), uint(id), models.Role(vars["role"]))
+	if err != nil {
+		sendRoleError(w, err)
+		return
+	}
+
+	SendSuccessResponse(w, user, "Role revoked successfully")
+}
+
+func sendRoleError(w http.ResponseWriter, err error) {
+	if errors.Is(err, services.ErrForbidden) {
+		SendErrorResponse(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	switch err.Error() {
+	case "invalid role":
+		SendErrorResponse(w, http.StatusBadRequest, err.Error())
+	case "user not found":
+		SendErrorResponse(w, http.StatusNotFound, err.Error())
+	case "cannot revoke the last role of a user", "cannot revoke seller role while the user has pets":
+		SendErrorResponse(w, http.StatusConflict, err.Error())
+	default:
+		SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"petstore-api/models"
+
+	"github.com/gorilla/mux"
+)
+
+// parseVersion reads the {version} path variable of the restore endpoints.
+func parseVersion(w http.ResponseWriter, r *http.Request) (int, bool) {
+	version, err := strconv.Atoi(mux.Vars(r)["version"])
+	if err != nil || version < 1 {
+		SendErrorResponse(w, http.StatusBadRequest, "Invalid version")
+		return 0, false
+	}
+	return version, true
+}
+
+// setETag sends the version of a pet or user as the entity tag of the
+// response, e.g. "3".
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// parseIfMatch reads the If-Match header. There is no precondition when the
+// header is missing or "*". Weak tags never match, as If-Match compares
+// entity tags strongly.
+func parseIfMatch(r *http.Request) models.VersionMatch {
+	header := strings.Join(r.Header.Values("If-Match"), ",")
+	if strings.TrimSpace(header) == "" {
+		return nil
+	}
+
+	match := models.VersionMatch{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return nil
+		}
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		if version, err := strconv.Atoi(tag[1 : len(tag)-1]); err == nil {
+			match = append(match, version)
+		}
+	}
+	return match
+}
+
+// sendVersionConflict answers an update that lost against another change:
+// 412 when the client's If-Match no longer holds, 409 when the record
+// changed while the request was being handled.
+func sendVersionConflict(w http.ResponseWriter, r *http.Request, err error) {
+	if parseIfMatch(r) != nil {
+		SendErrorResponse(w, http.StatusPreconditionFailed, err.Error())
+		return
+	}
+	SendErrorResponse(w, http.StatusConflict, err.Error())
+}
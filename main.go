@@ -7,15 +7,20 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"petstore-api/repositories/audit"
+	"petstore-api/repositories/orders"
 	"petstore-api/repositories/user_items"
 	"petstore-api/repositories/users"
 	"syscall"
 	"time"
 
+	"petstore-api/auth"
 	"petstore-api/config"
 	"petstore-api/handlers"
+	"petstore-api/payments"
 	"petstore-api/routes"
 	"petstore-api/services"
+	"petstore-api/storage"
 
 	_ "petstore-api/docs" // Swagger docs
 )
@@ -36,23 +41,79 @@ import (
 // @BasePath /
 // @schemes http https
 
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Type "Bearer" followed by a space and the access token from /auth/login
+
 func main() {
 	fmt.Println("Starting Pet Store API...")
 
+	authConfig := config.LoadAuthConfig()
+	tokens := auth.NewTokenManager([]byte(authConfig.JWTSecret), authConfig.TokenTTL)
+	paymentsConfig := config.LoadPaymentsConfig()
+	paymentProvider := payments.NewFakeProvider([]byte(paymentsConfig.WebhookSecret), paymentsConfig.WebhookURL, paymentsConfig.FakeFail)
+	storageConfig := config.LoadStorageConfig()
+	retentionConfig := config.LoadRetentionConfig()
+	blobStore, err := storage.NewLocalBlobStore(storageConfig.Dir, storageConfig.BaseURL)
+	if err != nil {
+		log.Fatalf("Failed to initialize blob storage: %v", err)
+	}
+
 	fmt.Println("Initializing database...")
 	db := config.InitDB()
 	fmt.Println("Database initialized successfully")
 
+	fmt.Println("Connecting to MongoDB...")
+	mongoDB, err := config.ConnectMongoDB(config.LoadMongoConfig())
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+
+	userRepo := users.NewUserRepository(db)
 	sellerRepo := users.NewSellerRepository(db)
+	buyerRepo := users.NewBuyerRepository(db)
 	petRepo := user_items.NewPetRepository(db)
-
-	sellerService := services.NewSellerService(sellerRepo, petRepo)
-	petService := services.NewPetService(petRepo, sellerRepo)
+	photoRepo := user_items.NewPhotoRepository(db)
+	healthRecordRepo := user_items.NewHealthRecordRepository(db)
+	pedigreeRepo := user_items.NewPedigreeRepository(db)
+	taxonomyRepo := user_items.NewTaxonomyRepository(db)
+	orderRepo := orders.NewOrderRepository(db)
+	paymentRepo := orders.NewPaymentRepository(db)
+	bucketRepo := user_items.NewBucketRepository(mongoDB.Database)
+	favouriteRepo := user_items.NewFavouriteRepository(mongoDB.Database)
+	auditRepo := audit.NewAuditRepository(mongoDB.Database)
+
+	sellerService := services.NewSellerService(sellerRepo, petRepo, auditRepo)
+	buyerService := services.NewBuyerService(buyerRepo, auditRepo)
+	bucketService := services.NewBucketService(bucketRepo, buyerRepo, petRepo)
+	orderService := services.NewOrderService(orderRepo, bucketRepo, buyerRepo, petRepo, auditRepo)
+	paymentService := services.NewPaymentService(paymentRepo, orderRepo, petRepo, auditRepo, paymentProvider, []byte(paymentsConfig.WebhookSecret))
+	favouriteService := services.NewFavouriteService(favouriteRepo, buyerRepo, petRepo)
+	roleService := services.NewRoleService(userRepo, petRepo, auditRepo)
+	petService := services.NewPetService(petRepo, userRepo, favouriteRepo, photoRepo, taxonomyRepo, auditRepo, blobStore)
+	photoService := services.NewPhotoService(photoRepo, petRepo, blobStore, storageConfig.ThumbnailWidth)
+	healthRecordService := services.NewHealthRecordService(healthRecordRepo, petRepo)
+	pedigreeService := services.NewPedigreeService(pedigreeRepo, petRepo, auditRepo)
+	taxonomyService := services.NewTaxonomyService(taxonomyRepo)
+	authService := services.NewAuthService(userRepo, auditRepo, tokens, authConfig.AdminEmail)
+	purgeService := services.NewPurgeService(petRepo, userRepo, photoRepo, blobStore)
 
 	sellerHandler := handlers.NewSellerHandler(sellerService)
+	buyerHandler := handlers.NewBuyerHandler(buyerService)
+	bucketHandler := handlers.NewBucketHandler(bucketService)
+	orderHandler := handlers.NewOrderHandler(orderService)
+	paymentHandler := handlers.NewPaymentHandler(paymentService)
+	favouriteHandler := handlers.NewFavouriteHandler(favouriteService)
+	userHandler := handlers.NewUserHandler(roleService)
+	authHandler := handlers.NewAuthHandler(authService)
 	petHandler := handlers.NewPetHandler(petService)
+	photoHandler := handlers.NewPhotoHandler(photoService, storageConfig.PhotoMaxBytes)
+	healthRecordHandler := handlers.NewHealthRecordHandler(healthRecordService)
+	pedigreeHandler := handlers.NewPedigreeHandler(pedigreeService)
+	taxonomyHandler := handlers.NewTaxonomyHandler(taxonomyService)
 
-	router := routes.SetupRoutes(sellerHandler, petHandler)
+	router := routes.SetupRoutes(sellerHandler, buyerHandler, bucketHandler, favouriteHandler, orderHandler, paymentHandler, userHandler, authHandler, petHandler, photoHandler, healthRecordHandler, pedigreeHandler, taxonomyHandler, blobStore.Handler(), authService)
 
 	server := &http.Server{
 		Addr:    ":8080",
@@ -63,16 +124,81 @@ func main() {
 		fmt.Println("🚀 Server starting on http://localhost:8080")
 		fmt.Println("📚 Swagger UI available at: http://localhost:8080/swagger/index.html")
 		fmt.Println("\nAvailable endpoints:")
+		fmt.Println("  POST   /auth/register")
+		fmt.Println("  POST   /auth/login")
+		fmt.Println("  GET    /auth/me")
 		fmt.Println("  GET    /sellers")
 		fmt.Println("  POST   /sellers")
 		fmt.Println("  GET    /sellers/{id}")
 		fmt.Println("  PUT    /sellers/{id}")
 		fmt.Println("  DELETE /sellers/{id}")
+		fmt.Println("  POST   /sellers/{id}/restore")
+		fmt.Println("  GET    /sellers/{id}/orders")
+		fmt.Println("  GET    /sellers/{id}/versions")
+		fmt.Println("  POST   /sellers/{id}/versions/{version}/restore")
+		fmt.Println("  POST   /sellers/{id}/pets/import")
+		fmt.Println("  GET    /sellers/{id}/pets/export")
+		fmt.Println("  GET    /buyers")
+		fmt.Println("  POST   /buyers")
+		fmt.Println("  GET    /buyers/{id}")
+		fmt.Println("  PUT    /buyers/{id}")
+		fmt.Println("  DELETE /buyers/{id}")
+		fmt.Println("  POST   /buyers/{id}/restore")
+		fmt.Println("  GET    /buyers/{id}/versions")
+		fmt.Println("  POST   /buyers/{id}/versions/{version}/restore")
+		fmt.Println("  GET    /buyers/{id}/cart")
+		fmt.Println("  POST   /buyers/{id}/cart")
+		fmt.Println("  DELETE /buyers/{id}/cart/{petId}")
+		fmt.Println("  POST   /buyers/{id}/checkout")
+		fmt.Println("  GET    /buyers/{id}/orders")
+		fmt.Println("  GET    /buyers/{id}/favourites")
+		fmt.Println("  PUT    /buyers/{id}/favourites/{petId}")
+		fmt.Println("  DELETE /buyers/{id}/favourites/{petId}")
+		fmt.Println("  GET    /orders/{id}")
+		fmt.Println("  POST   /orders/{id}/cancel")
+		fmt.Println("  PUT    /orders/{id}/status")
+		fmt.Println("  POST   /orders/{id}/pay")
+		fmt.Println("  POST   /orders/{id}/refund")
+		fmt.Println("  GET    /orders/{id}/payments")
+		fmt.Println("  POST   /webhooks/payments")
+		fmt.Println("  PUT    /users/{id}/roles/{role}")
+		fmt.Println("  DELETE /users/{id}/roles/{role}")
 		fmt.Println("  GET    /pets")
+		fmt.Println("  GET    /pets/search")
+		fmt.Println("  GET    /pets/facets")
+		fmt.Println("  GET    /pets/export")
 		fmt.Println("  POST   /pets")
 		fmt.Println("  GET    /pets/{id}")
 		fmt.Println("  PUT    /pets/{id}")
 		fmt.Println("  DELETE /pets/{id}")
+		fmt.Println("  POST   /pets/{id}/restore")
+		fmt.Println("  PUT    /pets/{id}/status")
+		fmt.Println("  GET    /pets/{id}/status-history")
+		fmt.Println("  GET    /pets/{id}/versions")
+		fmt.Println("  POST   /pets/{id}/versions/{version}/restore")
+		fmt.Println("  GET    /pets/{id}/photos")
+		fmt.Println("  POST   /pets/{id}/photos")
+		fmt.Println("  PUT    /pets/{id}/photos/order")
+		fmt.Println("  PUT    /pets/{id}/photos/{photoId}/primary")
+		fmt.Println("  DELETE /pets/{id}/photos/{photoId}")
+		fmt.Println("  GET    /pets/{id}/health-records")
+		fmt.Println("  POST   /pets/{id}/health-records")
+		fmt.Println("  PUT    /pets/{id}/health-records/{recordId}")
+		fmt.Println("  DELETE /pets/{id}/health-records/{recordId}")
+		fmt.Println("  GET    /pets/{id}/pedigree")
+		fmt.Println("  PUT    /pets/{id}/parents")
+		fmt.Println("  POST   /pedigree-entries")
+		fmt.Println("  GET    /pedigree-entries/{id}")
+		fmt.Println("  GET    /species")
+		fmt.Println("  POST   /species")
+		fmt.Println("  GET    /species/{id}")
+		fmt.Println("  PUT    /species/{id}")
+		fmt.Println("  DELETE /species/{id}")
+		fmt.Println("  GET    /species/{id}/breeds")
+		fmt.Println("  POST   /species/{id}/breeds")
+		fmt.Println("  PUT    /breeds/{id}")
+		fmt.Println("  DELETE /breeds/{id}")
+		fmt.Println("  GET    /media/...")
 		fmt.Println("\nPress Ctrl+C to stop the server")
 
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -80,6 +206,15 @@ func main() {
 		}
 	}()
 
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	purgeDone := make(chan struct{})
+	go func() {
+		defer close(purgeDone)
+		if retentionConfig.PurgeInterval > 0 {
+			purgeService.Run(purgeCtx, retentionConfig.PurgeInterval, retentionConfig.DeletedRetention)
+		}
+	}()
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
@@ -92,6 +227,12 @@ func main() {
 	if err := server.Shutdown(ctx); err != nil {
 		log.Printf("Server forced to shutdown: %v", err)
 	}
+	stopPurge()
+	<-purgeDone
+
+	if err := mongoDB.Disconnect(ctx); err != nil {
+		log.Printf("%v", err)
+	}
 
 	fmt.Println("✅ Server stopped gracefully")
 }
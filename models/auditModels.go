@@ -0,0 +1,171 @@
+package models
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+type AuditEntityType string
+
+const (
+	AuditEntityPet  AuditEntityType = "pet"
+	AuditEntityUser AuditEntityType = "user"
+)
+
+type AuditAction string
+
+const (
+	AuditActionCreate  AuditAction = "create"
+	AuditActionUpdate  AuditAction = "update"
+	AuditActionDelete  AuditAction = "delete"
+	AuditActionRestore AuditAction = "restore"
+	// AuditActionUndelete brings back a soft-deleted record.
+	AuditActionUndelete AuditAction = "undelete"
+)
+
+// AuditEntry is one version of a pet or user as stored in MongoDB. Versions
+// of an entity are numbered from 1. Snapshot is the record as it was after
+// the change, or as it was when deleted, so any entry can be restored.
+type AuditEntry struct {
+	EntityType   AuditEntityType `bson:"entityType" json:"entity_type"`
+	EntityID     uint            `bson:"entityId" json:"entity_id"`
+	Version      int             `bson:"version" json:"version"`
+	Action       AuditAction     `bson:"action" json:"action"`
+	ActorID      *uint           `bson:"actorId" json:"actor_id"`
+	Timestamp    time.Time       `bson:"timestamp" json:"timestamp"`
+	Changes      []FieldChange   `bson:"changes" json:"changes"`
+	Snapshot     map[string]any  `bson:"snapshot" json:"snapshot"`
+	RestoredFrom *int            `bson:"restoredFrom,omitempty" json:"restored_from,omitempty"`
+}
+
+// FieldChange is the before and after value of one field. Before is null
+// when the record was created and After is null when it was deleted.
+type FieldChange struct {
+	Field  string `bson:"field" json:"field"`
+	Before any    `bson:"before" json:"before"`
+	After  any    `bson:"after" json:"after"`
+}
+
+// PetSnapshot holds the audited fields of a pet. Parents are recorded so
+// pedigree changes show up in the history, but are not restored with a
+// version; they go through the pedigree endpoint and its cycle check.
+type PetSnapshot struct {
+	Name          string    `json:"name"`
+	Species       string    `json:"species"`
+	Breed         string    `json:"breed"`
+	Age           int       `json:"age"`
+	PriceAmount   int64     `json:"price_amount"`
+	PriceCurrency string    `json:"price_currency"`
+	Description   string    `json:"description"`
+	Status        PetStatus `json:"status"`
+	SellerID      uint      `json:"seller_id"`
+	SireID        *uint     `json:"sire_id"`
+	DamID         *uint     `json:"dam_id"`
+	SireEntryID   *uint     `json:"sire_entry_id"`
+	DamEntryID    *uint     `json:"dam_entry_id"`
+}
+
+func NewPetSnapshot(pet *Pet) *PetSnapshot {
+	return &PetSnapshot{
+		Name:          pet.Name,
+		Species:       pet.Species,
+		Breed:         pet.Breed,
+		Age:           pet.Age,
+		PriceAmount:   pet.Price.Amount,
+		PriceCurrency: pet.Price.Currency,
+		Description:   pet.Description,
+		Status:        pet.Status,
+		SellerID:      pet.SellerID,
+		SireID:        pet.SireID,
+		DamID:         pet.DamID,
+		SireEntryID:   pet.SireEntryID,
+		DamEntryID:    pet.DamEntryID,
+	}
+}
+
+// UserSnapshot holds the audited fields of a user. Roles are listed so
+// that removing a seller or buyer account shows up in the history.
+type UserSnapshot struct {
+	Name    string   `json:"name"`
+	Email   string   `json:"email"`
+	Phone   string   `json:"phone"`
+	Address string   `json:"address"`
+	Roles   []string `json:"roles"`
+}
+
+func NewUserSnapshot(user *User) *UserSnapshot {
+	roles := make([]string, len(user.Roles))
+	for i, role := range user.Roles {
+		roles[i] = string(role.Role)
+	}
+	sort.Strings(roles)
+
+	return &UserSnapshot{
+		Name:    user.Name,
+		Email:   user.Email,
+		Phone:   user.Phone,
+		Address: user.Address,
+		Roles:   roles,
+	}
+}
+
+// AuditSnapshot turns a snapshot struct into the map stored with an entry,
+// keyed by the JSON field names.
+func AuditSnapshot(snapshot any) map[string]any {
+	names, values := auditFields(snapshot)
+	fields := make(map[string]any, len(names))
+	for i, name := range names {
+		fields[name] = values[i]
+	}
+	return fields
+}
+
+// AuditChanges lists the fields that differ between two snapshots of the
+// same type, in field order. A nil before or after stands for a record that
+// did not exist yet or no longer does.
+func AuditChanges(before, after any) []FieldChange {
+	beforeNames, beforeValues := auditFields(before)
+	afterNames, afterValues := auditFields(after)
+
+	names := afterNames
+	if names == nil {
+		names = beforeNames
+	}
+
+	changes := []FieldChange{}
+	for i, name := range names {
+		var change FieldChange
+		change.Field = name
+		if beforeValues != nil {
+			change.Before = beforeValues[i]
+		}
+		if afterValues != nil {
+			change.After = afterValues[i]
+		}
+		if !reflect.DeepEqual(change.Before, change.After) {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// auditFields returns the JSON names and values of the fields of a pointer
+// to a snapshot struct, or nothing for a nil pointer.
+func auditFields(snapshot any) ([]string, []any) {
+	v := reflect.ValueOf(snapshot)
+	if !v.IsValid() || (v.Kind() == reflect.Pointer && v.IsNil()) {
+		return nil, nil
+	}
+	v = reflect.Indirect(v)
+
+	t := v.Type()
+	names := make([]string, t.NumField())
+	values := make([]any, t.NumField())
+	for i := range names {
+		names[i], _, _ = strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		values[i] = v.Field(i).Interface()
+	}
+	return names, values
+}
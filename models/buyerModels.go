@@ -1,15 +1,31 @@
 package models
 
+// Bucket is a buyer's shopping cart as stored in MongoDB. Only pet IDs are
+// kept there; the pets themselves always come from Postgres.
 type Bucket struct {
-	UserId uint
-	Pets   []Pet
+	UserID uint   `bson:"userId"`
+	PetIDs []uint `bson:"pets"`
 }
 
-type Favorite struct {
-	UserId uint
-	Pets   []Pet
+// Favourite is a buyer's list of favourite pets as stored in MongoDB.
+type Favourite struct {
+	UserID uint   `bson:"userId"`
+	PetIDs []uint `bson:"pets"`
 }
 
-type Buyer struct {
-	User
+// Cart totals are given per currency, since pets priced in different
+// currencies cannot be added up.
+type Cart struct {
+	BuyerID uint    `json:"buyer_id"`
+	Pets    []Pet   `json:"pets"`
+	Totals  []Money `json:"totals"`
+}
+
+type AddToCartRequest struct {
+	PetID uint `json:"pet_id" binding:"required"`
+}
+
+type Favourites struct {
+	BuyerID uint  `json:"buyer_id"`
+	Pets    []Pet `json:"pets"`
 }
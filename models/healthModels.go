@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+type HealthRecordType string
+
+const (
+	HealthRecordVaccine   HealthRecordType = "vaccine"
+	HealthRecordTreatment HealthRecordType = "treatment"
+	HealthRecordVetCheck  HealthRecordType = "vet_check"
+)
+
+func (t HealthRecordType) Valid() bool {
+	switch t {
+	case HealthRecordVaccine, HealthRecordTreatment, HealthRecordVetCheck:
+		return true
+	}
+	return false
+}
+
+// HealthRecord is one entry in a pet's health history: a vaccination, a
+// treatment such as deworming or microchipping, or a vet check. A vaccine
+// without a NextDueDate never needs a booster.
+type HealthRecord struct {
+	ID          uint             `json:"id" gorm:"primaryKey;autoIncrement"`
+	PetID       uint             `json:"pet_id" gorm:"not null;index"`
+	Pet         *Pet             `json:"-" gorm:"foreignKey:PetID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Type        HealthRecordType `json:"type" gorm:"not null;size:20;index"`
+	Name        string           `json:"name" gorm:"not null;size:255"`
+	Date        Date             `json:"date" gorm:"not null" swaggertype:"string" format:"date" example:"2024-05-01"`
+	Vet         string           `json:"vet" gorm:"size:255"`
+	NextDueDate *Date            `json:"next_due_date,omitempty" swaggertype:"string" format:"date" example:"2025-05-01"`
+	Notes       string           `json:"notes,omitempty" gorm:"type:text"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+type HealthRecordRequest struct {
+	Type        HealthRecordType `json:"type" binding:"required" enums:"vaccine,treatment,vet_check"`
+	Name        string           `json:"name" binding:"required"`
+	Date        *Date            `json:"date" binding:"required" swaggertype:"string" format:"date" example:"2024-05-01"`
+	Vet         string           `json:"vet"`
+	NextDueDate *Date            `json:"next_due_date" swaggertype:"string" format:"date" example:"2025-05-01"`
+	Notes       string           `json:"notes"`
+}
+
+// Validate reports problems keyed by JSON field name. today is passed in so
+// a record dated in the future can be refused.
+func (r *HealthRecordRequest) Validate(today Date) error {
+	var verr ValidationError
+
+	if !r.Type.Valid() {
+		verr.Add("type", "must be one of vaccine, treatment, vet_check")
+	}
+	if r.Name == "" {
+		verr.Add("name", "is required")
+	}
+	if r.Date == nil {
+		verr.Add("date", "is required")
+	} else if r.Date.After(today.Time) {
+		verr.Add("date", "must not be in the future")
+	}
+	if r.Date != nil && r.NextDueDate != nil && !r.NextDueDate.After(r.Date.Time) {
+		verr.Add("next_due_date", "must be after date")
+	}
+
+	return verr.Err()
+}
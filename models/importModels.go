@@ -0,0 +1,83 @@
+package models
+
+import (
+	"errors"
+	"sort"
+)
+
+// PetImportMode decides what happens to the valid rows of an import when
+// other rows fail.
+type PetImportMode string
+
+const (
+	// PetImportAllOrNothing imports nothing unless every row succeeds.
+	PetImportAllOrNothing PetImportMode = "all_or_nothing"
+	// PetImportPartial imports every row that succeeds and reports the rest.
+	PetImportPartial PetImportMode = "partial"
+)
+
+// MaxPetImportRows caps the number of pets in a single import.
+const MaxPetImportRows = 1000
+
+func (m PetImportMode) Valid() bool {
+	return m == PetImportAllOrNothing || m == PetImportPartial
+}
+
+// PetImportRow is one pet read from an import file. Line is where the row
+// starts in the file. Errors holds problems found while parsing the row,
+// such as a non-numeric age, before the pet itself is validated.
+type PetImportRow struct {
+	Line   int
+	Pet    CreatePetRequest
+	Errors *ValidationError
+}
+
+type PetImportOptions struct {
+	DryRun bool
+	Mode   PetImportMode
+}
+
+// PetImportRowError explains why a row was not imported. Fields is set when
+// the problem is with particular columns, Error otherwise.
+type PetImportRowError struct {
+	Line   int               `json:"line"`
+	Error  string            `json:"error,omitempty"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+type ImportedPet struct {
+	Line  int  `json:"line"`
+	PetID uint `json:"pet_id"`
+}
+
+// PetImportReport sums up an import. Rows counts every row read, Valid those
+// that passed validation and Imported those that were stored, which is
+// always 0 for a dry run.
+type PetImportReport struct {
+	DryRun   bool                `json:"dry_run"`
+	Mode     PetImportMode       `json:"mode"`
+	Rows     int                 `json:"rows"`
+	Valid    int                 `json:"valid"`
+	Imported int                 `json:"imported"`
+	Errors   []PetImportRowError `json:"errors"`
+	Pets     []ImportedPet       `json:"pets"`
+}
+
+// AddError records why the row on line was not imported.
+func (r *PetImportReport) AddError(line int, err error) {
+	rowErr := PetImportRowError{Line: line}
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		rowErr.Fields = verr.Fields
+	} else {
+		rowErr.Error = err.Error()
+	}
+	r.Errors = append(r.Errors, rowErr)
+}
+
+// SortErrors puts the row errors in file order.
+func (r *PetImportReport) SortErrors() {
+	sort.SliceStable(r.Errors, func(i, j int) bool {
+		return r.Errors[i].Line < r.Errors[j].Line
+	})
+}
@@ -1,15 +1,37 @@
 package models
 
-import "time"
+import (
+	"time"
 
+	"gorm.io/gorm"
+)
+
+// User is soft-deleted: DeletedAt is set instead of removing the row, and
+// queries skip such users unless asked otherwise. Emails only have to be
+// unique among users that are not deleted. Version goes up with every
+// change to the user's own fields and is sent as the ETag of the user.
 type User struct {
-	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
-	Name      string    `json:"name" gorm:"not null;size:255"`
-	Email     string    `json:"email" gorm:"unique;not null;size:255"`
-	Phone     string    `json:"phone" gorm:"size:20"`
-	Address   string    `json:"address" gorm:"size:500"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name         string         `json:"name" gorm:"not null;size:255"`
+	Email        string         `json:"email" gorm:"not null;size:255;uniqueIndex:idx_users_active_email,where:deleted_at IS NULL"`
+	Phone        string         `json:"phone" gorm:"size:20"`
+	Address      string         `json:"address" gorm:"size:500"`
+	PasswordHash string         `json:"-" gorm:"size:255"`
+	Version      int            `json:"version" gorm:"not null;default:1"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index" swaggertype:"string" format:"date-time"`
+	Roles        []UserRole     `json:"roles" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Pets         []Pet          `json:"pets,omitempty" gorm:"-"`
+}
+
+func (u *User) HasRole(role Role) bool {
+	for _, r := range u.Roles {
+		if r.Role == role {
+			return true
+		}
+	}
+	return false
 }
 
 type CreateUserRequest struct {
@@ -0,0 +1,149 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultCurrency is used for prices given without a currency.
+const DefaultCurrency = "USD"
+
+var (
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrInvalidCurrency  = errors.New("currency must be a three-letter ISO 4217 code")
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrNegativeAmount   = errors.New("amount must not be negative")
+
+	currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// currencyExponents lists ISO 4217 currencies whose minor unit is not a
+// hundredth. Every other currency has two decimal places.
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// Money is an exact amount in the minor unit of its currency, e.g. cents
+// for USD. It is stored as two columns when embedded in a model.
+type Money struct {
+	Amount   int64  `json:"amount" gorm:"not null;default:0"`
+	Currency string `json:"currency" gorm:"not null;size:3;default:'USD'"`
+}
+
+// CurrencyExponent returns the number of decimal places of the currency's
+// minor unit.
+func CurrencyExponent(currency string) int {
+	if exp, ok := currencyExponents[currency]; ok {
+		return exp
+	}
+	return 2
+}
+
+// FromMajorUnits returns a whole number of major units, e.g. 50 USD as 5000
+// cents.
+func FromMajorUnits(amount int64, currency string) Money {
+	return Money{Amount: amount * minorUnitScale(currency), Currency: currency}
+}
+
+func minorUnitScale(currency string) int64 {
+	scale := int64(1)
+	for i := 0; i < CurrencyExponent(currency); i++ {
+		scale *= 10
+	}
+	return scale
+}
+
+// ParseMoney reads a decimal amount in major units, such as "19.99", without
+// going through floating point. More decimal places than the currency has
+// are rejected rather than rounded.
+func ParseMoney(value string, currency string) (Money, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	if !currencyCode.MatchString(currency) {
+		return Money{}, ErrInvalidCurrency
+	}
+
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+	if !ok {
+		return Money{}, ErrInvalidAmount
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(CurrencyExponent(currency))), nil)
+	r.Mul(r, new(big.Rat).SetInt(scale))
+	if !r.IsInt() || !r.Num().IsInt64() {
+		return Money{}, ErrInvalidAmount
+	}
+
+	return Money{Amount: r.Num().Int64(), Currency: currency}, nil
+}
+
+// Normalize fills in the default currency and upper-cases the code.
+func (m Money) Normalize() Money {
+	m.Currency = strings.ToUpper(strings.TrimSpace(m.Currency))
+	if m.Currency == "" {
+		m.Currency = DefaultCurrency
+	}
+	return m
+}
+
+func (m Money) Validate() error {
+	if !currencyCode.MatchString(m.Currency) {
+		return ErrInvalidCurrency
+	}
+	if m.Amount < 0 {
+		return ErrNegativeAmount
+	}
+	return nil
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+// String formats the amount in major units, e.g. "19.99 USD".
+func (m Money) String() string {
+	return m.MajorUnits() + " " + m.Currency
+}
+
+// MajorUnits formats the amount in major units without the currency, e.g.
+// "19.99", in the form ParseMoney reads.
+func (m Money) MajorUnits() string {
+	exp := CurrencyExponent(m.Currency)
+	if exp == 0 {
+		return fmt.Sprintf("%d", m.Amount)
+	}
+
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	scale := minorUnitScale(m.Currency)
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/scale, exp, amount%scale)
+}
+
+// SumByCurrency adds up amounts per currency, since different currencies
+// cannot be combined. The result is ordered by currency code.
+func SumByCurrency(amounts []Money) []Money {
+	totals := make(map[string]int64)
+	for _, m := range amounts {
+		totals[m.Currency] += m.Amount
+	}
+
+	result := make([]Money, 0, len(totals))
+	for currency, amount := range totals {
+		result = append(result, Money{Amount: amount, Currency: currency})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Currency < result[j].Currency })
+	return result
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		currency string
+		want     Money
+		wantErr  error
+	}{
+		{"cents", "19.99", "USD", Money{1999, "USD"}, nil},
+		{"whole amount", "20", "USD", Money{2000, "USD"}, nil},
+		{"one decimal", "0.5", "EUR", Money{50, "EUR"}, nil},
+		{"trailing zeros are exact", "19.990", "USD", Money{1999, "USD"}, nil},
+		{"default currency", "1.00", "", Money{100, DefaultCurrency}, nil},
+		{"currency is normalized", " 1 ", " eur ", Money{100, "EUR"}, nil},
+		{"negative", "-5.25", "USD", Money{-525, "USD"}, nil},
+		{"zero decimal currency", "1500", "JPY", Money{1500, "JPY"}, nil},
+		{"three decimal currency", "1.234", "KWD", Money{1234, "KWD"}, nil},
+		{"too many decimals are not rounded", "19.999", "USD", Money{}, ErrInvalidAmount},
+		{"decimals on zero decimal currency", "1500.5", "JPY", Money{}, ErrInvalidAmount},
+		{"fourth decimal on three decimal currency", "1.2345", "KWD", Money{}, ErrInvalidAmount},
+		{"not a number", "abc", "USD", Money{}, ErrInvalidAmount},
+		{"empty", "", "USD", Money{}, ErrInvalidAmount},
+		{"overflows int64", "99999999999999999999", "USD", Money{}, ErrInvalidAmount},
+		{"invalid currency", "1.00", "US", Money{}, ErrInvalidCurrency},
+		{"currency with digits", "1.00", "U5D", Money{}, ErrInvalidCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMoney(tt.value, tt.currency)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseMoney(%q, %q) error = %v, want %v", tt.value, tt.currency, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMoney(%q, %q) = %+v, want %+v", tt.value, tt.currency, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyMajorUnits(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{Money{1999, "USD"}, "19.99"},
+		{Money{5, "USD"}, "0.05"},
+		{Money{0, "USD"}, "0.00"},
+		{Money{-525, "USD"}, "-5.25"},
+		{Money{-5, "USD"}, "-0.05"},
+		{Money{1500, "JPY"}, "1500"},
+		{Money{-1500, "JPY"}, "-1500"},
+		{Money{1234, "KWD"}, "1.234"},
+		{Money{7, "KWD"}, "0.007"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.money.Currency+"/"+tt.want, func(t *testing.T) {
+			if got := tt.money.MajorUnits(); got != tt.want {
+				t.Errorf("%+v.MajorUnits() = %q, want %q", tt.money, got, tt.want)
+			}
+
+			back, err := ParseMoney(tt.want, tt.money.Currency)
+			if err != nil || back != tt.money {
+				t.Errorf("ParseMoney(%q) = %+v, %v, want %+v", tt.want, back, err, tt.money)
+			}
+		})
+	}
+}
+
+func TestFromMajorUnits(t *testing.T) {
+	tests := []struct {
+		amount   int64
+		currency string
+		want     int64
+	}{
+		{50, "USD", 5000},
+		{50, "JPY", 50},
+		{50, "BHD", 50000},
+	}
+
+	for _, tt := range tests {
+		if got := FromMajorUnits(tt.amount, tt.currency); got.Amount != tt.want || got.Currency != tt.currency {
+			t.Errorf("FromMajorUnits(%d, %q) = %+v, want %d", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package models
+
+import "time"
+
+type OrderStatus string
+
+const (
+	OrderStatusPending    OrderStatus = "pending"
+	OrderStatusPaid       OrderStatus = "paid"
+	OrderStatusHandedOver OrderStatus = "handed_over"
+	OrderStatusCompleted  OrderStatus = "completed"
+	OrderStatusCancelled  OrderStatus = "cancelled"
+	OrderStatusRefunded   OrderStatus = "refunded"
+)
+
+// orderTransitions lists the statuses each status may move to. Cancelled,
+// refunded and completed orders are final.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:    {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:       {OrderStatusHandedOver, OrderStatusRefunded},
+	OrderStatusHandedOver: {OrderStatusCompleted, OrderStatusRefunded},
+}
+
+func (s OrderStatus) Valid() bool {
+	switch s {
+	case OrderStatusPending, OrderStatusPaid, OrderStatusHandedOver,
+		OrderStatusCompleted, OrderStatusCancelled, OrderStatusRefunded:
+		return true
+	}
+	return false
+}
+
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// ReleasesPets reports whether entering this status gives the order's pets
+// back to the catalog.
+func (s OrderStatus) ReleasesPets() bool {
+	return s == OrderStatusCancelled || s == OrderStatusRefunded
+}
+
+// SellsPets reports whether entering this status marks the order's pets
+// sold.
+func (s OrderStatus) SellsPets() bool {
+	return s == OrderStatusCompleted
+}
+
+type Order struct {
+	ID        uint                 `json:"id" gorm:"primaryKey;autoIncrement"`
+	BuyerID   uint                 `json:"buyer_id" gorm:"not null;index"`
+	Buyer     *User                `json:"buyer,omitempty" gorm:"foreignKey:BuyerID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	Status    OrderStatus          `json:"status" gorm:"not null;size:20;index"`
+	Total     Money                `json:"total" gorm:"embedded;embeddedPrefix:total_"`
+	Items     []OrderItem          `json:"items" gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE"`
+	History   []OrderStatusHistory `json:"history,omitempty" gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+func (o *Order) HasSeller(sellerID uint) bool {
+	for _, item := range o.Items {
+		if item.SellerID == sellerID {
+			return true
+		}
+	}
+	return false
+}
+
+// SoldOnlyBy reports whether every item in the order belongs to sellerID.
+func (o *Order) SoldOnlyBy(sellerID uint) bool {
+	for _, item := range o.Items {
+		if item.SellerID != sellerID {
+			return false
+		}
+	}
+	return len(o.Items) > 0
+}
+
+func (o *Order) PetIDs() []uint {
+	ids := make([]uint, len(o.Items))
+	for i, item := range o.Items {
+		ids[i] = item.PetID
+	}
+	return ids
+}
+
+// OrderItem is one pet in an order. Price is copied from the pet at checkout
+// so later price changes do not alter what the buyer agreed to pay.
+type OrderItem struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	OrderID   uint      `json:"order_id" gorm:"not null;index"`
+	PetID     uint      `json:"pet_id" gorm:"not null;index"`
+	Pet       *Pet      `json:"pet,omitempty" gorm:"foreignKey:PetID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	SellerID  uint      `json:"seller_id" gorm:"not null;index"`
+	Price     Money     `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OrderStatusHistory records every status change of an order, including the
+// initial move into pending at checkout (FromStatus is empty then).
+type OrderStatusHistory struct {
+	ID         uint        `json:"id" gorm:"primaryKey;autoIncrement"`
+	OrderID    uint        `json:"order_id" gorm:"not null;index"`
+	FromStatus OrderStatus `json:"from_status" gorm:"size:20"`
+	ToStatus   OrderStatus `json:"to_status" gorm:"not null;size:20"`
+	ChangedBy  *uint       `json:"changed_by"`
+	Note       string      `json:"note,omitempty" gorm:"size:500"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+type UpdateOrderStatusRequest struct {
+	Status OrderStatus `json:"status" binding:"required"`
+	Note   string      `json:"note"`
+}
+
+type CancelOrderRequest struct {
+	Note string `json:"note"`
+}
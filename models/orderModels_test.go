@@ -0,0 +1,51 @@
+package models
+
+import "testing"
+
+func TestOrderStatusCanTransitionTo(t *testing.T) {
+	statuses := []OrderStatus{
+		OrderStatusPending, OrderStatusPaid, OrderStatusHandedOver,
+		OrderStatusCompleted, OrderStatusCancelled, OrderStatusRefunded,
+	}
+	allowed := map[OrderStatus][]OrderStatus{
+		OrderStatusPending:    {OrderStatusPaid, OrderStatusCancelled},
+		OrderStatusPaid:       {OrderStatusHandedOver, OrderStatusRefunded},
+		OrderStatusHandedOver: {OrderStatusCompleted, OrderStatusRefunded},
+	}
+
+	for _, from := range statuses {
+		for _, to := range statuses {
+			want := false
+			for _, next := range allowed[from] {
+				want = want || next == to
+			}
+			if got := from.CanTransitionTo(to); got != want {
+				t.Errorf("%s -> %s = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestOrderStatusPetEffects(t *testing.T) {
+	tests := []struct {
+		status   OrderStatus
+		releases bool
+		sells    bool
+	}{
+		{OrderStatusPending, false, false},
+		{OrderStatusPaid, false, false},
+		{OrderStatusHandedOver, false, false},
+		{OrderStatusCompleted, false, true},
+		{OrderStatusCancelled, true, false},
+		{OrderStatusRefunded, true, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.status.ReleasesPets(); got != tt.releases {
+			t.Errorf("%s.ReleasesPets() = %v, want %v", tt.status, got, tt.releases)
+		}
+		if got := tt.status.SellsPets(); got != tt.sells {
+			t.Errorf("%s.SellsPets() = %v, want %v", tt.status, got, tt.sells)
+		}
+	}
+}
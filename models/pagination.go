@@ -0,0 +1,45 @@
+package models
+
+import "errors"
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PageRequest asks for one page of a list. Cursor is the NextCursor of the
+// previous page and is empty for the first one; it is only valid with the
+// same Sort it was issued for.
+type PageRequest struct {
+	Limit  int
+	Cursor string
+	Sort   []SortField
+}
+
+// SortField is one entry of a sort parameter such as "-price".
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Size returns the number of rows to load, falling back to the default and
+// capping at MaxPageLimit.
+func (p PageRequest) Size() int {
+	switch {
+	case p.Limit <= 0:
+		return DefaultPageLimit
+	case p.Limit > MaxPageLimit:
+		return MaxPageLimit
+	}
+	return p.Limit
+}
+
+// PageInfo describes the page that was returned. NextCursor is empty on the
+// last page; Total counts every row matching the request, not just this page.
+type PageInfo struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      int64  `json:"total"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+type PaymentStatus string
+
+const (
+	PaymentStatusProcessing PaymentStatus = "processing"
+	PaymentStatusSucceeded  PaymentStatus = "succeeded"
+	PaymentStatusFailed     PaymentStatus = "failed"
+	PaymentStatusRefunded   PaymentStatus = "refunded"
+)
+
+// paymentTransitions lists the statuses each payment status may move to. A
+// success reported after a failure is kept so the money can be refunded.
+// Refunded payments are final.
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	PaymentStatusProcessing: {PaymentStatusSucceeded, PaymentStatusFailed},
+	PaymentStatusFailed:     {PaymentStatusSucceeded},
+	PaymentStatusSucceeded:  {PaymentStatusRefunded},
+}
+
+func (s PaymentStatus) CanTransitionTo(next PaymentStatus) bool {
+	for _, allowed := range paymentTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Payment is one attempt to pay for an order through a payment provider. A
+// failed attempt leaves the order pending so the buyer can try again.
+type Payment struct {
+	ID           uint          `json:"id" gorm:"primaryKey;autoIncrement"`
+	OrderID      uint          `json:"order_id" gorm:"not null;index"`
+	Order        *Order        `json:"-" gorm:"foreignKey:OrderID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	Provider     string        `json:"provider" gorm:"not null;size:50"`
+	IntentID     string        `json:"intent_id" gorm:"not null;size:255;uniqueIndex"`
+	ClientSecret string        `json:"client_secret,omitempty" gorm:"-"`
+	Amount       Money         `json:"amount" gorm:"embedded"`
+	Status       PaymentStatus `json:"status" gorm:"not null;size:20"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+// PaymentEvent remembers every webhook event already applied, keyed by the
+// provider's event ID, so redelivered events are ignored.
+type PaymentEvent struct {
+	ID          string    `json:"id" gorm:"primaryKey;size:255"`
+	Type        string    `json:"type" gorm:"not null;size:50"`
+	IntentID    string    `json:"intent_id" gorm:"not null;size:255;index"`
+	ProcessedAt time.Time `json:"processed_at" gorm:"autoCreateTime"`
+}
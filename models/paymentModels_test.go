@@ -0,0 +1,25 @@
+package models
+
+import "testing"
+
+func TestPaymentStatusCanTransitionTo(t *testing.T) {
+	statuses := []PaymentStatus{PaymentStatusProcessing, PaymentStatusSucceeded, PaymentStatusFailed, PaymentStatusRefunded}
+	allowed := map[PaymentStatus][]PaymentStatus{
+		PaymentStatusProcessing: {PaymentStatusSucceeded, PaymentStatusFailed},
+		// A success reported after a failure is kept so it can be refunded.
+		PaymentStatusFailed:    {PaymentStatusSucceeded},
+		PaymentStatusSucceeded: {PaymentStatusRefunded},
+	}
+
+	for _, from := range statuses {
+		for _, to := range statuses {
+			want := false
+			for _, next := range allowed[from] {
+				want = want || next == to
+			}
+			if got := from.CanTransitionTo(to); got != want {
+				t.Errorf("%s -> %s = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package models
+
+import "time"
+
+const (
+	DefaultPedigreeGenerations = 3
+	MaxPedigreeGenerations     = 10
+)
+
+// PedigreeNode kinds.
+const (
+	PedigreeKindPet   = "pet"
+	PedigreeKindEntry = "pedigree_entry"
+)
+
+// PedigreeEntry is an ancestor that is not listed on the platform, such as
+// a registered stud animal. Its parents can only be other entries, and they
+// are fixed when it is created, so entries never form a cycle.
+type PedigreeEntry struct {
+	ID                 uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name               string         `json:"name" gorm:"not null;size:255"`
+	Species            string         `json:"species" gorm:"not null;size:100"`
+	Breed              string         `json:"breed" gorm:"size:100"`
+	Registry           string         `json:"registry,omitempty" gorm:"size:100"`
+	RegistrationNumber string         `json:"registration_number,omitempty" gorm:"size:100"`
+	SireID             *uint          `json:"sire_id,omitempty" gorm:"index"`
+	DamID              *uint          `json:"dam_id,omitempty" gorm:"index"`
+	Sire               *PedigreeEntry `json:"-" gorm:"foreignKey:SireID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	Dam                *PedigreeEntry `json:"-" gorm:"foreignKey:DamID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	CreatedAt          time.Time      `json:"created_at"`
+}
+
+type CreatePedigreeEntryRequest struct {
+	Name               string `json:"name" binding:"required"`
+	Species            string `json:"species" binding:"required"`
+	Breed              string `json:"breed"`
+	Registry           string `json:"registry"`
+	RegistrationNumber string `json:"registration_number"`
+	SireID             *uint  `json:"sire_id"`
+	DamID              *uint  `json:"dam_id"`
+}
+
+// Validate checks the fields that do not need the database.
+func (r *CreatePedigreeEntryRequest) Validate() error {
+	var verr ValidationError
+
+	if r.Name == "" {
+		verr.Add("name", "is required")
+	}
+	if r.Species == "" {
+		verr.Add("species", "is required")
+	}
+	if r.SireID != nil && r.DamID != nil && *r.SireID == *r.DamID {
+		verr.Add("dam_id", "must differ from sire_id")
+	}
+
+	return verr.Err()
+}
+
+// ParentRef points at either a listed pet or a pedigree entry.
+type ParentRef struct {
+	PetID   *uint `json:"pet_id,omitempty"`
+	EntryID *uint `json:"entry_id,omitempty"`
+}
+
+// SetParentsRequest replaces both parents of a pet. A missing parent is
+// cleared.
+type SetParentsRequest struct {
+	Sire *ParentRef `json:"sire"`
+	Dam  *ParentRef `json:"dam"`
+}
+
+// Validate checks the shape of the request. Whether the parents exist, match
+// the pet's species and keep the lineage acyclic is checked by the service.
+func (r *SetParentsRequest) Validate(petID uint) error {
+	var verr ValidationError
+
+	for _, parent := range []struct {
+		field string
+		ref   *ParentRef
+	}{{"sire", r.Sire}, {"dam", r.Dam}} {
+		if parent.ref == nil {
+			continue
+		}
+		if (parent.ref.PetID == nil) == (parent.ref.EntryID == nil) {
+			verr.Add(parent.field, "must set exactly one of pet_id and entry_id")
+		} else if parent.ref.PetID != nil && *parent.ref.PetID == petID {
+			verr.Add(parent.field, "a pet cannot be its own parent")
+		}
+	}
+	if r.Sire != nil && r.Dam != nil && sameRef(r.Sire, r.Dam) {
+		verr.Add("dam", "must differ from sire")
+	}
+
+	return verr.Err()
+}
+
+func sameRef(a, b *ParentRef) bool {
+	equal := func(x, y *uint) bool {
+		return x != nil && y != nil && *x == *y
+	}
+	return equal(a.PetID, b.PetID) || equal(a.EntryID, b.EntryID)
+}
+
+// PedigreeNode is one animal in an ancestry tree. The same ancestor can
+// appear more than once when it is on both sides of the family.
+type PedigreeNode struct {
+	Kind               string        `json:"kind" enums:"pet,pedigree_entry"`
+	ID                 uint          `json:"id"`
+	Name               string        `json:"name"`
+	Species            string        `json:"species"`
+	Breed              string        `json:"breed"`
+	RegistrationNumber string        `json:"registration_number,omitempty"`
+	Sire               *PedigreeNode `json:"sire,omitempty"`
+	Dam                *PedigreeNode `json:"dam,omitempty"`
+}
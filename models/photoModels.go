@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// PetPhoto is an uploaded picture of a pet. The image and its thumbnail live
+// in blob storage under Key and ThumbnailKey; URL and ThumbnailURL are filled
+// in from the store when the photo is returned. Photos are shown in Position
+// order, and exactly one photo of a pet with photos is primary.
+type PetPhoto struct {
+	ID           uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	PetID        uint      `json:"pet_id" gorm:"not null;index"`
+	Pet          *Pet      `json:"-" gorm:"foreignKey:PetID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Key          string    `json:"-" gorm:"not null;size:255"`
+	ThumbnailKey string    `json:"-" gorm:"not null;size:255"`
+	URL          string    `json:"url" gorm:"-"`
+	ThumbnailURL string    `json:"thumbnail_url" gorm:"-"`
+	ContentType  string    `json:"content_type" gorm:"not null;size:50"`
+	Size         int64     `json:"size"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	Position     int       `json:"position" gorm:"not null;default:0"`
+	IsPrimary    bool      `json:"is_primary" gorm:"not null;default:false"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type ReorderPhotosRequest struct {
+	PhotoIDs []uint `json:"photo_ids" binding:"required"`
+}
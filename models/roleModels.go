@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+type Role string
+
+const (
+	RoleSeller Role = "seller"
+	RoleBuyer  Role = "buyer"
+	RoleAdmin  Role = "admin"
+)
+
+func (r Role) Valid() bool {
+	switch r {
+	case RoleSeller, RoleBuyer, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+type UserRole struct {
+	UserID    uint      `json:"-" gorm:"primaryKey"`
+	Role      Role      `json:"role" gorm:"primaryKey;size:20"`
+	CreatedAt time.Time `json:"granted_at"`
+}
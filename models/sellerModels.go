@@ -2,46 +2,242 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
-type Seller struct {
-	User
-	Pets []Pet `json:"pets,omitempty" gorm:"foreignKey:SellerID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+type PetStatus string
+
+const (
+	PetStatusDraft     PetStatus = "draft"
+	PetStatusListed    PetStatus = "listed"
+	PetStatusReserved  PetStatus = "reserved"
+	PetStatusSold      PetStatus = "sold"
+	PetStatusWithdrawn PetStatus = "withdrawn"
+)
+
+// PetStatuses lists every pet status in lifecycle order.
+var PetStatuses = []PetStatus{PetStatusDraft, PetStatusListed, PetStatusReserved, PetStatusSold, PetStatusWithdrawn}
+
+// petTransitions lists the status changes a seller can make. Reserved and
+// sold are entered and left only through orders: checkout reserves a listed
+// pet, completing the order sells it and cancelling or refunding it lists
+// the pet again.
+var petTransitions = map[PetStatus][]PetStatus{
+	PetStatusDraft:     {PetStatusListed, PetStatusWithdrawn},
+	PetStatusListed:    {PetStatusDraft, PetStatusWithdrawn},
+	PetStatusWithdrawn: {PetStatusDraft, PetStatusListed},
+}
+
+func (s PetStatus) Valid() bool {
+	switch s {
+	case PetStatusDraft, PetStatusListed, PetStatusReserved, PetStatusSold, PetStatusWithdrawn:
+		return true
+	}
+	return false
+}
+
+func (s PetStatus) CanTransitionTo(next PetStatus) bool {
+	for _, allowed := range petTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Public reports whether pets in this status are shown to everyone. Drafts
+// and withdrawn pets are only visible to their seller and admins.
+func (s PetStatus) Public() bool {
+	return s != PetStatusDraft && s != PetStatusWithdrawn
 }
 
+// Pet is a listing. Each parent is either another pet (SireID, DamID) or an
+// external pedigree entry (SireEntryID, DamEntryID), so at most one of each
+// pair is set. Deleting a pet only sets DeletedAt; the row is purged once
+// the retention period has passed. Version goes up with every change to the
+// pet's row and is sent as the ETag of the pet.
 type Pet struct {
-	ID          uint      `json:"id" gorm:"primaryKey;autoIncrement"`
-	Name        string    `json:"name" gorm:"not null;size:255"`
-	Species     string    `json:"species" gorm:"not null;size:100"`
-	Breed       string    `json:"breed" gorm:"size:100"`
-	Age         int       `json:"age" gorm:"check:age >= 0"`
-	Price       float64   `json:"price" gorm:"type:decimal(10,2);check:price >= 0"`
-	Description string    `json:"description" gorm:"type:text"`
-	Available   bool      `json:"available" gorm:"default:true"`
-	SellerID    uint      `json:"seller_id" gorm:"not null;index"`
-	Seller      *Seller   `json:"seller,omitempty" gorm:"foreignKey:SellerID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID              uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name            string         `json:"name" gorm:"not null;size:255"`
+	Species         string         `json:"species" gorm:"not null;size:100"`
+	Breed           string         `json:"breed" gorm:"size:100"`
+	Age             int            `json:"age" gorm:"check:age >= 0"`
+	Price           Money          `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	Description     string         `json:"description" gorm:"type:text"`
+	Status          PetStatus      `json:"status" gorm:"not null;size:20;default:listed;index"`
+	StatusChangedAt time.Time      `json:"status_changed_at"`
+	SellerID        uint           `json:"seller_id" gorm:"not null;index"`
+	FavouriteCount  int64          `json:"favourite_count" gorm:"-"`
+	Photos          []PetPhoto     `json:"photos,omitempty" gorm:"-"`
+	Seller          *User          `json:"seller,omitempty" gorm:"foreignKey:SellerID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	SireID          *uint          `json:"sire_id,omitempty" gorm:"index"`
+	DamID           *uint          `json:"dam_id,omitempty" gorm:"index"`
+	SireEntryID     *uint          `json:"sire_entry_id,omitempty" gorm:"index"`
+	DamEntryID      *uint          `json:"dam_entry_id,omitempty" gorm:"index"`
+	Sire            *Pet           `json:"-" gorm:"foreignKey:SireID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	Dam             *Pet           `json:"-" gorm:"foreignKey:DamID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	SireEntry       *PedigreeEntry `json:"-" gorm:"foreignKey:SireEntryID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	DamEntry        *PedigreeEntry `json:"-" gorm:"foreignKey:DamEntryID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	Version         int            `json:"version" gorm:"not null;default:1"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index" swaggertype:"string" format:"date-time"`
 }
 
+// CreatePetRequest lists the pet straight away unless Status asks for a
+// draft.
 type CreatePetRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	Species     string  `json:"species" binding:"required"`
-	Breed       string  `json:"breed"`
-	Age         int     `json:"age"`
-	Price       float64 `json:"price"`
-	Description string  `json:"description"`
-	Available   bool    `json:"available"`
-	SellerID    uint    `json:"seller_id" binding:"required"`
+	Name        string    `json:"name" binding:"required"`
+	Species     string    `json:"species" binding:"required"`
+	Breed       string    `json:"breed"`
+	Age         int       `json:"age"`
+	Price       Money     `json:"price"`
+	Description string    `json:"description"`
+	Status      PetStatus `json:"status" enums:"draft,listed"`
+	SellerID    uint      `json:"seller_id"`
 }
 
+// UpdatePetRequest leaves fields that are empty or nil untouched. Age and
+// Price are pointers so that they can be set to 0.
 type UpdatePetRequest struct {
-	Name        string  `json:"name"`
-	Species     string  `json:"species"`
-	Breed       string  `json:"breed"`
-	Age         int     `json:"age"`
-	Price       float64 `json:"price"`
-	Description string  `json:"description"`
-	Available   *bool   `json:"available"`
-	SellerID    uint    `json:"seller_id"`
+	Name        string `json:"name"`
+	Species     string `json:"species"`
+	Breed       string `json:"breed"`
+	Age         *int   `json:"age"`
+	Price       *Money `json:"price"`
+	Description string `json:"description"`
+	SellerID    uint   `json:"seller_id"`
+}
+
+// PetStatusHistory records every status change of a pet, including the
+// initial status at creation (FromStatus is empty then).
+type PetStatusHistory struct {
+	ID         uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	PetID      uint      `json:"pet_id" gorm:"not null;index"`
+	Pet        *Pet      `json:"-" gorm:"foreignKey:PetID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	FromStatus PetStatus `json:"from_status" gorm:"size:20"`
+	ToStatus   PetStatus `json:"to_status" gorm:"not null;size:20"`
+	ChangedBy  *uint     `json:"changed_by"`
+	Note       string    `json:"note,omitempty" gorm:"size:500"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type UpdatePetStatusRequest struct {
+	Status PetStatus `json:"status" binding:"required" enums:"draft,listed,withdrawn"`
+	Note   string    `json:"note"`
+}
+
+// PetSortFields are the fields GET /pets can be sorted by.
+var PetSortFields = []string{"name", "species", "breed", "age", "price", "created_at", "updated_at"}
+
+// PetFilter narrows a pet listing. Nil pointers and empty slices leave that
+// criterion out. Species and breeds match case-insensitively against any of
+// the given values. When Currency is set only pets priced in it match, and
+// the price bounds are in that currency. A pet counts as vaccinated when it
+// has a vaccine health record that is not yet due again. IncludeDeleted
+// adds soft-deleted pets, which only admins may ask for.
+type PetFilter struct {
+	SellerID       *uint
+	Species        []string
+	Breeds         []string
+	Currency       string
+	MinPrice       *Money
+	MaxPrice       *Money
+	MinAge         *int
+	MaxAge         *int
+	Statuses       []PetStatus
+	Vaccinated     *bool
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+	IncludeDeleted bool
+}
+
+// Validate checks the bounds against each other. Errors are keyed by the
+// query parameter names used by GET /pets.
+func (f PetFilter) Validate() error {
+	var verr ValidationError
+
+	if f.MinPrice != nil && f.MaxPrice != nil && f.MaxPrice.Amount < f.MinPrice.Amount {
+		verr.Add("max_price", "must not be less than min_price")
+	}
+	if f.MinAge != nil && f.MaxAge != nil && *f.MaxAge < *f.MinAge {
+		verr.Add("max_age", "must not be less than min_age")
+	}
+	if f.CreatedAfter != nil && f.CreatedBefore != nil && !f.CreatedBefore.After(*f.CreatedAfter) {
+		verr.Add("created_before", "must be later than created_after")
+	}
+
+	return verr.Err()
+}
+
+// PetSearchResult is a pet matched by full-text search. Snippet is an
+// excerpt of the description with the matched words wrapped in <mark> tags.
+type PetSearchResult struct {
+	Pet
+	Rank    float32 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// PetFacetOptions sets the bucket bounds for price and age facets. Each
+// bucket runs from one bound up to, but not including, the next; the last is
+// open-ended. Pets below the first bound are not counted. All price bounds
+// share one currency and only pets priced in it are bucketed.
+//
+// The status facet ignores the filter's own statuses so that it shows what
+// else there is to pick, and counts the pets in StatusScope instead. When
+// StatusScope is empty it follows the filter like the other facets.
+type PetFacetOptions struct {
+	PriceBounds []Money
+	AgeBounds   []int
+	StatusScope []PetStatus
+}
+
+// Default facet bounds. Price bounds are whole major units of the currency.
+var (
+	DefaultPriceBounds = []int64{0, 50, 100, 250, 500, 1000}
+	DefaultAgeBounds   = []int{0, 1, 3, 7, 12}
+)
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+type StatusFacetCount struct {
+	Status PetStatus `json:"status"`
+	Count  int64     `json:"count"`
+}
+
+type SellerFacetCount struct {
+	SellerID   uint   `json:"seller_id"`
+	SellerName string `json:"seller_name"`
+	Count      int64  `json:"count"`
+}
+
+// PriceBucketCount counts pets priced from Min up to, but not including,
+// Max. Max is omitted for the last bucket.
+type PriceBucketCount struct {
+	Min   Money  `json:"min"`
+	Max   *Money `json:"max,omitempty"`
+	Count int64  `json:"count"`
+}
+
+// AgeBucketCount counts pets aged from Min up to, but not including, Max.
+// Max is omitted for the last bucket.
+type AgeBucketCount struct {
+	Min   int   `json:"min"`
+	Max   *int  `json:"max,omitempty"`
+	Count int64 `json:"count"`
+}
+
+// PetFacets are catalog counts for the pets matching a filter.
+type PetFacets struct {
+	Total    int64              `json:"total"`
+	Species  []FacetCount       `json:"species"`
+	Breeds   []FacetCount       `json:"breeds"`
+	Statuses []StatusFacetCount `json:"statuses"`
+	Sellers  []SellerFacetCount `json:"sellers"`
+	Prices   []PriceBucketCount `json:"prices"`
+	Ages     []AgeBucketCount   `json:"ages"`
 }
@@ -0,0 +1,44 @@
+package models
+
+import "testing"
+
+func TestPetStatusCanTransitionTo(t *testing.T) {
+	// Reserved and sold are only entered and left through orders, so a
+	// seller can never move a pet into or out of them.
+	allowed := map[PetStatus][]PetStatus{
+		PetStatusDraft:     {PetStatusListed, PetStatusWithdrawn},
+		PetStatusListed:    {PetStatusDraft, PetStatusWithdrawn},
+		PetStatusWithdrawn: {PetStatusDraft, PetStatusListed},
+	}
+
+	for _, from := range PetStatuses {
+		for _, to := range PetStatuses {
+			want := false
+			for _, next := range allowed[from] {
+				want = want || next == to
+			}
+			if got := from.CanTransitionTo(to); got != want {
+				t.Errorf("%s -> %s = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestPetStatusPublic(t *testing.T) {
+	tests := []struct {
+		status PetStatus
+		want   bool
+	}{
+		{PetStatusDraft, false},
+		{PetStatusListed, true},
+		{PetStatusReserved, true},
+		{PetStatusSold, true},
+		{PetStatusWithdrawn, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.status.Public(); got != tt.want {
+			t.Errorf("%s.Public() = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Species is a canonical species name. Pets are stored with this name, and
+// lookups also accept any of its aliases, ignoring case.
+type Species struct {
+	ID        uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name      string         `json:"name" gorm:"not null;size:100;uniqueIndex:idx_species_name,expression:lower(name)"`
+	Aliases   []SpeciesAlias `json:"aliases" gorm:"foreignKey:SpeciesID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" swaggertype:"array,string"`
+	Breeds    []Breed        `json:"breeds,omitempty" gorm:"foreignKey:SpeciesID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+type SpeciesAlias struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	SpeciesID uint   `gorm:"not null;index"`
+	Name      string `gorm:"not null;size:100;uniqueIndex:idx_species_alias_name,expression:lower(name)"`
+}
+
+// MarshalJSON writes an alias as just its name.
+func (a SpeciesAlias) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.Name)
+}
+
+// Breed is a canonical breed name within a species.
+type Breed struct {
+	ID        uint         `json:"id" gorm:"primaryKey;autoIncrement"`
+	SpeciesID uint         `json:"species_id" gorm:"not null;uniqueIndex:idx_breed_name,priority:1"`
+	Name      string       `json:"name" gorm:"not null;size:100;uniqueIndex:idx_breed_name,priority:2,expression:lower(name)"`
+	Aliases   []BreedAlias `json:"aliases" gorm:"foreignKey:BreedID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" swaggertype:"array,string"`
+	Species   *Species     `json:"-" gorm:"foreignKey:SpeciesID"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// BreedAlias carries the species of its breed so that aliases can be kept
+// unique per species.
+type BreedAlias struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	BreedID   uint   `gorm:"not null;index"`
+	SpeciesID uint   `gorm:"not null;uniqueIndex:idx_breed_alias_name,priority:1"`
+	Name      string `gorm:"not null;size:100;uniqueIndex:idx_breed_alias_name,priority:2,expression:lower(name)"`
+}
+
+func (a BreedAlias) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.Name)
+}
+
+// TaxonomyRequest creates or replaces a species or a breed. Aliases replace
+// the existing ones.
+type TaxonomyRequest struct {
+	Name    string   `json:"name" binding:"required"`
+	Aliases []string `json:"aliases"`
+}
+
+// Validate trims the names and checks that no two of them are the same.
+func (r *TaxonomyRequest) Validate() error {
+	var verr ValidationError
+
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		verr.Add("name", "is required")
+	}
+
+	seen := map[string]bool{strings.ToLower(r.Name): true}
+	for i, alias := range r.Aliases {
+		alias = strings.TrimSpace(alias)
+		r.Aliases[i] = alias
+		if alias == "" {
+			verr.Add("aliases", "must not contain empty names")
+			break
+		}
+		if seen[strings.ToLower(alias)] {
+			verr.Add("aliases", "must not repeat the name or another alias")
+			break
+		}
+		seen[strings.ToLower(alias)] = true
+	}
+
+	return verr.Err()
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+// ValidationError collects problems with individual request fields, keyed by
+// the field or query parameter name, so clients can point at each one.
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ValidationError) Add(field, message string) {
+	if e.Fields == nil {
+		e.Fields = make(map[string]string)
+	}
+	if _, exists := e.Fields[field]; !exists {
+		e.Fields[field] = message
+	}
+}
+
+func (e *ValidationError) HasErrors() bool {
+	return len(e.Fields) > 0
+}
+
+// Err returns e when any field failed, and nil otherwise.
+func (e *ValidationError) Err() error {
+	if !e.HasErrors() {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field := range e.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		parts[i] = field + ": " + e.Fields[field]
+	}
+	return "invalid parameters: " + strings.Join(parts, "; ")
+}
@@ -0,0 +1,18 @@
+package models
+
+// VersionMatch is an If-Match precondition on the version of a pet or user.
+// A nil VersionMatch matches any version; an empty one, left when none of
+// the tags named a version, matches none.
+type VersionMatch []int
+
+func (m VersionMatch) Matches(version int) bool {
+	if m == nil {
+		return true
+	}
+	for _, v := range m {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
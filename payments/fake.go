@@ -0,0 +1,125 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"petstore-api/models"
+)
+
+var ErrUnknownIntent = errors.New("unknown payment intent")
+
+// FakeProvider is an in-memory provider for local development and offline
+// runs. It reports captures and refunds by posting signed events to
+// webhookURL, exactly like a real provider would, so the webhook path is
+// exercised end to end. With failCaptures set every capture fails.
+type FakeProvider struct {
+	secret       []byte
+	webhookURL   string
+	failCaptures bool
+	client       *http.Client
+
+	mu      sync.Mutex
+	intents map[string]*Intent
+}
+
+func NewFakeProvider(secret []byte, webhookURL string, failCaptures bool) *FakeProvider {
+	return &FakeProvider{
+		secret:       secret,
+		webhookURL:   webhookURL,
+		failCaptures: failCaptures,
+		client:       &http.Client{Timeout: 5 * time.Second},
+		intents:      make(map[string]*Intent),
+	}
+}
+
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakeProvider) CreateIntent(ctx context.Context, amount models.Money, reference string) (*Intent, error) {
+	intent := &Intent{
+		ID:           "pi_fake_" + randomID(),
+		ClientSecret: "secret_" + randomID(),
+		Amount:       amount,
+	}
+
+	p.mu.Lock()
+	p.intents[intent.ID] = intent
+	p.mu.Unlock()
+
+	return intent, nil
+}
+
+func (p *FakeProvider) Capture(ctx context.Context, intentID string) error {
+	if !p.known(intentID) {
+		return ErrUnknownIntent
+	}
+
+	eventType := EventPaymentSucceeded
+	if p.failCaptures {
+		eventType = EventPaymentFailed
+	}
+	go p.deliver(Event{ID: "evt_fake_" + randomID(), Type: eventType, IntentID: intentID, CreatedAt: time.Now()})
+	return nil
+}
+
+func (p *FakeProvider) Refund(ctx context.Context, intentID string) error {
+	if !p.known(intentID) {
+		return ErrUnknownIntent
+	}
+
+	go p.deliver(Event{ID: "evt_fake_" + randomID(), Type: EventPaymentRefunded, IntentID: intentID, CreatedAt: time.Now()})
+	return nil
+}
+
+func (p *FakeProvider) known(intentID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.intents[intentID]
+	return ok
+}
+
+func (p *FakeProvider) deliver(event Event) {
+	if p.webhookURL == "" {
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("fake payments: failed to encode event %s: %v", event.ID, err)
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		log.Printf("fake payments: failed to build webhook request: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(p.secret, payload))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		log.Printf("fake payments: failed to deliver event %s: %v", event.ID, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("fake payments: webhook rejected event %s with status %d", event.ID, resp.StatusCode)
+	}
+}
+
+func randomID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
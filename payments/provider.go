@@ -0,0 +1,73 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"petstore-api/models"
+)
+
+const (
+	EventPaymentSucceeded = "payment.succeeded"
+	EventPaymentFailed    = "payment.failed"
+	EventPaymentRefunded  = "payment.refunded"
+
+	// SignatureHeader carries the hex HMAC-SHA256 of the raw webhook body,
+	// prefixed with "sha256=".
+	SignatureHeader = "X-Payment-Signature"
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Intent is a provider-side request to collect a given amount.
+type Intent struct {
+	ID           string
+	ClientSecret string
+	Amount       models.Money
+}
+
+// Event is the body of a payment webhook.
+type Event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	IntentID  string    `json:"intent_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Provider is implemented by every payment backend. Outcomes of Capture and
+// Refund are reported asynchronously through signed webhook events.
+type Provider interface {
+	Name() string
+	CreateIntent(ctx context.Context, amount models.Money, reference string) (*Intent, error)
+	Capture(ctx context.Context, intentID string) error
+	Refund(ctx context.Context, intentID string) error
+}
+
+func Sign(secret []byte, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func VerifySignature(secret []byte, payload []byte, signature string) error {
+	hexSum, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return ErrInvalidSignature
+	}
+	got, err := hex.DecodeString(hexSum)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package payments
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestVerifySignature(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	payload := []byte(`{"id":"evt_1","type":"payment.succeeded","intent_id":"pi_1"}`)
+	valid := Sign(secret, payload)
+
+	tests := []struct {
+		name      string
+		secret    []byte
+		payload   []byte
+		signature string
+		wantErr   error
+	}{
+		{"valid", secret, payload, valid, nil},
+		{"upper case hex", secret, payload, "sha256=" + strings.ToUpper(strings.TrimPrefix(valid, "sha256=")), nil},
+		{"other secret", []byte("fedcba9876543210fedcba9876543210"), payload, valid, ErrInvalidSignature},
+		{"tampered payload", secret, []byte(`{"id":"evt_1","type":"payment.refunded","intent_id":"pi_1"}`), valid, ErrInvalidSignature},
+		{"missing prefix", secret, payload, strings.TrimPrefix(valid, "sha256="), ErrInvalidSignature},
+		{"other algorithm", secret, payload, "sha1=" + strings.TrimPrefix(valid, "sha256="), ErrInvalidSignature},
+		{"not hex", secret, payload, "sha256=zz", ErrInvalidSignature},
+		{"truncated", secret, payload, valid[:len(valid)-2], ErrInvalidSignature},
+		{"empty", secret, payload, "", ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifySignature(tt.secret, tt.payload, tt.signature)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifySignature() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package audit
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"petstore-api/models"
+	"petstore-api/repositories"
+)
+
+// recordAttempts bounds how often Record retries when another writer took
+// the version it picked.
+const recordAttempts = 5
+
+type auditRepo struct {
+	collection *mongo.Collection
+}
+
+// NewAuditRepository stores audit entries in the "audit_log" collection. A
+// unique index on entity and version keeps concurrent writers from giving
+// two entries the same version.
+func NewAuditRepository(db *mongo.Database) repositories.AuditRepository {
+	collection := db.Collection("audit_log")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "entityType", Value: 1}, {Key: "entityId", Value: 1}, {Key: "version", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Printf("failed to create audit log index: %v", err)
+	}
+
+	return &auditRepo{collection: collection}
+}
+
+func (a *auditRepo) Record(entry *models.AuditEntry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var err error
+	for attempt := 0; attempt < recordAttempts; attempt++ {
+		var latest models.AuditEntry
+		err = a.collection.FindOne(ctx, entityFilter(entry.EntityType, entry.EntityID),
+			options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}}).SetProjection(bson.M{"version": 1}),
+		).Decode(&latest)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return err
+		}
+
+		entry.Version = latest.Version + 1
+		_, err = a.collection.InsertOne(ctx, entry)
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func (a *auditRepo) GetHistory(entityType models.AuditEntityType, entityID uint) ([]models.AuditEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := a.collection.Find(ctx, entityFilter(entityType, entityID),
+		options.Find().SetSort(bson.D{{Key: "version", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []models.AuditEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (a *auditRepo) GetVersion(entityType models.AuditEntityType, entityID uint, version int) (*models.AuditEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := entityFilter(entityType, entityID)
+	filter["version"] = version
+
+	var entry models.AuditEntry
+	if err := a.collection.FindOne(ctx, filter).Decode(&entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func entityFilter(entityType models.AuditEntityType, entityID uint) bson.M {
+	return bson.M{"entityType": entityType, "entityId": entityID}
+}
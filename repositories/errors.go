@@ -0,0 +1,51 @@
+package repositories
+
+import "errors"
+
+var (
+	// ErrPetUnavailable is returned when a pet that should be reserved has
+	// been taken or withdrawn in the meantime.
+	ErrPetUnavailable = errors.New("pet is not available")
+
+	// ErrPetReserved is returned when a pet cannot be deleted because an
+	// open order has reserved it.
+	ErrPetReserved = errors.New("pet is reserved by an order")
+
+	// ErrInvalidTransition is returned when an order is asked to move to a
+	// status its current status does not allow.
+	ErrInvalidTransition = errors.New("invalid status transition")
+
+	// ErrPhotoSetMismatch is returned when a new photo order does not list
+	// exactly the pet's photos.
+	ErrPhotoSetMismatch = errors.New("photo ids do not match the pet's photos")
+
+	// ErrPedigreeCycle is returned when a parent assignment would make a pet
+	// its own ancestor.
+	ErrPedigreeCycle = errors.New("pet would become its own ancestor")
+
+	// ErrSpeciesInUse is returned when a species cannot be deleted because
+	// pets still have it.
+	ErrSpeciesInUse = errors.New("species is still used by pets")
+
+	// ErrEmailTaken is returned when a user is given an email another user
+	// already has, or a deleted user cannot be restored because another
+	// user has registered the email since.
+	ErrEmailTaken = errors.New("email is already in use by another user")
+
+	// ErrVersionConflict is returned when a record was changed by someone
+	// else since it was read, so an update would overwrite their change.
+	ErrVersionConflict = errors.New("record was changed by another request")
+
+	// ErrOrderNotPending is returned when a payment is started for an order
+	// that is no longer waiting to be paid.
+	ErrOrderNotPending = errors.New("only pending orders can be paid")
+
+	// ErrPaymentInProgress is returned when a payment is started for an
+	// order that already has a processing or successful payment.
+	ErrPaymentInProgress = errors.New("order already has a payment in progress")
+
+	// ErrEventOutOfOrder is returned when a payment event does not fit the
+	// current status of the payment or its order. The event is recorded but
+	// changes nothing.
+	ErrEventOutOfOrder = errors.New("payment event does not fit the payment or order status")
+)
@@ -1,26 +1,193 @@
 package repositories
 
-import "petstore-api/models"
+import (
+	"time"
+
+	"petstore-api/models"
+)
 
 type UserRepository interface {
-	GetAll(includePets bool) ([]models.User, error)
+	GetAll(includePets bool, page models.PageRequest) ([]models.User, *models.PageInfo, error)
 	GetByID(id uint, includePets bool) (*models.User, error)
-	Create(seller *models.User) error
-	Update(seller *models.User) error
+	GetByEmail(email string) (*models.User, error)
+	Create(user *models.User) error
+	// Update returns ErrEmailTaken if another user already has the email.
+	Update(user *models.User) error
 	Delete(id uint) error
+	GrantRole(userID uint, role models.Role) error
+	RevokeRole(userID uint, role models.Role) error
+	// AnyWithRole reports whether some user holds role.
+	AnyWithRole(role models.Role) (bool, error)
+	// Unscoped returns a repository that also sees soft-deleted users.
+	Unscoped() UserRepository
+	// Restore undeletes a soft-deleted user, returning ErrEmailTaken if
+	// another user has registered the email since.
+	Restore(id uint) error
+	// Purge permanently removes users deleted before cutoff who no longer
+	// own pets or orders, and returns how many went.
+	Purge(cutoff time.Time) (int64, error)
 }
 
 type PetRepository interface {
-	GetAll(includeSeller bool, sellerID *uint) ([]models.Pet, error)
+	GetAll(includeSeller bool, filter models.PetFilter, page models.PageRequest) ([]models.Pet, *models.PageInfo, error)
+	// Search returns the pets matching a web-search style query, best
+	// matches first.
+	Search(text string, filter models.PetFilter, page models.PageRequest) ([]models.PetSearchResult, *models.PageInfo, error)
+	// Facets counts the pets matching filter by species, breed,
+	// status, seller and the given price and age buckets. See
+	// PetFacetOptions for how the status facet is scoped.
+	Facets(filter models.PetFilter, options models.PetFacetOptions) (*models.PetFacets, error)
+	// Export calls fn for every pet matching filter in ID order. Rows are
+	// read from the database as they are needed rather than loaded at
+	// once; an error from fn stops the export and is returned.
+	Export(filter models.PetFilter, fn func(pet *models.Pet) error) error
 	GetByID(id uint, includeSeller bool) (*models.Pet, error)
-	Create(pet *models.Pet) error
+	GetByIDs(ids []uint) ([]models.Pet, error)
+	Create(pet *models.Pet, changedBy *uint) error
+	// CreateMany stores pets in batches inside one transaction and returns
+	// the error of each pet, by index, that could not be stored. Unless
+	// partial is set, any failure rolls back every pet.
+	CreateMany(pets []models.Pet, changedBy *uint, partial bool) (map[int]error, error)
 	Update(pet *models.Pet) error
+	// Transition moves a pet to a new status if a seller may make that
+	// change, returning ErrInvalidTransition otherwise, and records it. Like
+	// Update it fails with ErrVersionConflict if the pet is no longer at
+	// version.
+	Transition(id uint, version int, to models.PetStatus, changedBy *uint, note string) (*models.Pet, error)
+	GetStatusHistory(id uint) ([]models.PetStatusHistory, error)
+	// Delete soft-deletes the pet, returning ErrPetReserved if an order
+	// has reserved it.
 	Delete(id uint) error
 	GetBySellerID(sellerID uint) ([]models.Pet, error)
+	// Unscoped returns a repository that also sees soft-deleted pets.
+	Unscoped() PetRepository
+	Restore(id uint) error
+	// GetPurgeable returns up to limit pets deleted before cutoff that can
+	// be purged. Pets that were ordered are kept for the order history.
+	GetPurgeable(cutoff time.Time, limit int) ([]uint, error)
+	// Purge permanently removes the given pets with their photos, health
+	// records and status history.
+	Purge(ids []uint) error
+}
+
+type PhotoRepository interface {
+	// Create stores a new photo as the pet's last one. The first photo of
+	// a pet becomes its primary photo.
+	Create(photo *models.PetPhoto) error
+	GetByID(id uint) (*models.PetPhoto, error)
+	// GetByPetIDs returns the photos of the given pets in display order.
+	GetByPetIDs(petIDs []uint) ([]models.PetPhoto, error)
+	Delete(photo *models.PetPhoto) error
+	// Reorder fails with ErrPhotoSetMismatch unless photoIDs lists every
+	// photo of the pet exactly once.
+	Reorder(petID uint, photoIDs []uint) error
+	SetPrimary(photo *models.PetPhoto) error
+}
+
+type HealthRecordRepository interface {
+	Create(record *models.HealthRecord) error
+	GetByID(id uint) (*models.HealthRecord, error)
+	// GetByPetID returns a pet's records, most recent first.
+	GetByPetID(petID uint) ([]models.HealthRecord, error)
+	Update(record *models.HealthRecord) error
+	Delete(id uint) error
+}
+
+type PedigreeRepository interface {
+	CreateEntry(entry *models.PedigreeEntry) error
+	GetEntryByID(id uint) (*models.PedigreeEntry, error)
+	// SetParents stores the four parent columns of pet, refusing with
+	// ErrPedigreeCycle an assignment that would close a loop and with
+	// ErrVersionConflict one made to a pet that is no longer at pet.Version.
+	// On success pet.Version holds the new version.
+	SetParents(pet *models.Pet) error
+	// Ancestry returns the pet's ancestry tree up to the given number of
+	// generations.
+	Ancestry(petID uint, generations int) (*models.PedigreeNode, error)
+}
+
+type TaxonomyRepository interface {
+	// GetAllSpecies returns every species with its aliases and breeds.
+	GetAllSpecies() ([]models.Species, error)
+	GetSpeciesByID(id uint) (*models.Species, error)
+	// FindSpecies looks a species up by its name or an alias, ignoring case.
+	FindSpecies(name string) (*models.Species, error)
+	CreateSpecies(species *models.Species) error
+	UpdateSpecies(species *models.Species, previousName string) error
+	// DeleteSpecies returns ErrSpeciesInUse if a pet that is not deleted
+	// still has the species.
+	DeleteSpecies(id uint) error
+	GetBreedByID(id uint) (*models.Breed, error)
+	// FindBreed looks a breed of the species up by its name or an alias,
+	// ignoring case.
+	FindBreed(speciesID uint, name string) (*models.Breed, error)
+	CreateBreed(breed *models.Breed) error
+	UpdateBreed(breed *models.Breed, previousName string) error
+	DeleteBreed(id uint) error
+}
+
+type OrderRepository interface {
+	// CreateFromPets places an order for the given pets in one transaction:
+	// the pets are locked, their current prices copied onto the order items,
+	// and they are reserved. If any pet is no longer listed the whole order
+	// fails with ErrPetUnavailable.
+	CreateFromPets(buyerID uint, petIDs []uint) (*models.Order, error)
+	GetByID(id uint) (*models.Order, error)
+	GetByBuyerID(buyerID uint, page models.PageRequest) ([]models.Order, *models.PageInfo, error)
+	// GetBySellerID returns the orders containing at least one of the
+	// seller's pets, with only that seller's items loaded.
+	GetBySellerID(sellerID uint, page models.PageRequest) ([]models.Order, *models.PageInfo, error)
+	// Transition moves an order to a new status under a row lock, records
+	// the change in the status history and moves the order's pets along:
+	// cancellations and refunds list them again, completion sells them.
+	Transition(id uint, to models.OrderStatus, changedBy *uint, note string) (*models.Order, error)
+}
+
+type PaymentRepository interface {
+	// CreateProcessing stores a new processing payment while holding a lock
+	// on its order, so concurrent attempts for the same order are serialised.
+	// It fails with ErrOrderNotPending when the order is no longer pending and
+	// with ErrPaymentInProgress when another attempt is processing or has
+	// succeeded.
+	CreateProcessing(payment *models.Payment) error
+	// MarkFailed fails a payment that is still processing, freeing its order
+	// for another attempt. A payment that has moved on is left as is.
+	MarkFailed(id uint) error
+	GetByIntentID(intentID string) (*models.Payment, error)
+	GetByOrderID(orderID uint) ([]models.Payment, error)
+	// ApplyEvent stores a provider event and, the first time its ID is seen,
+	// sets the payment status and moves the order to orderStatus (when not
+	// empty) in the same transaction. applied is false for a redelivered
+	// event. An order that can no longer move to orderStatus is left as is
+	// and orderMoved reports false. An event the payment or order status
+	// does not allow is recorded without effect and reported with
+	// ErrEventOutOfOrder.
+	ApplyEvent(event *models.PaymentEvent, status models.PaymentStatus, orderStatus models.OrderStatus) (payment *models.Payment, applied bool, orderMoved bool, err error)
 }
 
+// UserItemRepository keeps per-user lists of pet IDs in MongoDB.
 type UserItemRepository interface {
 	AddPet(userID uint, petID uint) error
 	RemovePet(userID uint, petID uint) error
-	GetPetsByBuyerID(userID uint) ([]models.Pet, error)
+	GetPetIDs(userID uint) ([]uint, error)
+	Clear(userID uint) error
+}
+
+type FavouriteRepository interface {
+	UserItemRepository
+	// CountByPetIDs returns how many users have favourited each of the given
+	// pets. Pets nobody has favourited are missing from the map.
+	CountByPetIDs(petIDs []uint) (map[uint]int64, error)
+}
+
+// AuditRepository keeps the version history of pets and users.
+type AuditRepository interface {
+	// Record stores entry as the next version of its entity and sets
+	// entry.Version accordingly.
+	Record(entry *models.AuditEntry) error
+	// GetHistory returns every version of an entity, oldest first.
+	GetHistory(entityType models.AuditEntityType, entityID uint) ([]models.AuditEntry, error)
+	// GetVersion returns mongo.ErrNoDocuments when the version does not
+	// exist.
+	GetVersion(entityType models.AuditEntityType, entityID uint, version int) (*models.AuditEntry, error)
 }
@@ -0,0 +1,163 @@
+package orders
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"petstore-api/models"
+	"petstore-api/repositories"
+)
+
+type orderRepository struct {
+	db *gorm.DB
+}
+
+func NewOrderRepository(db *gorm.DB) repositories.OrderRepository {
+	return &orderRepository{db: db}
+}
+
+func (r *orderRepository) CreateFromPets(buyerID uint, petIDs []uint) (*models.Order, error) {
+	order := &models.Order{
+		BuyerID: buyerID,
+		Status:  models.OrderStatusPending,
+		History: []models.OrderStatusHistory{
+			{ToStatus: models.OrderStatusPending, ChangedBy: &buyerID},
+		},
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Row locks make a concurrent checkout of the same pet wait for this
+		// transaction and then see the pet as unavailable.
+		var pets []models.Pet
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ? AND status = ?", petIDs, models.PetStatusListed).
+			Order("id").
+			Find(&pets).Error
+		if err != nil {
+			return err
+		}
+		if len(pets) != len(petIDs) {
+			return repositories.ErrPetUnavailable
+		}
+
+		order.Total = models.Money{Currency: pets[0].Price.Currency}
+		for _, pet := range pets {
+			order.Items = append(order.Items, models.OrderItem{
+				PetID:    pet.ID,
+				SellerID: pet.SellerID,
+				Price:    pet.Price,
+			})
+			if order.Total, err = order.Total.Add(pet.Price); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+
+		return repositories.TransitionPets(tx, petIDs, models.PetStatusListed, models.PetStatusReserved, &buyerID, "checkout")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return order, nil
+}
+
+func (r *orderRepository) GetByID(id uint) (*models.Order, error) {
+	var order models.Order
+
+	result := r.db.Preload("Items.Pet", withDeleted).Preload("History", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at, id")
+	}).First(&order, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &order, nil
+}
+
+func (r *orderRepository) GetByBuyerID(buyerID uint, page models.PageRequest) ([]models.Order, *models.PageInfo, error) {
+	query := r.db.Preload("Items.Pet", withDeleted).Where("buyer_id = ?", buyerID)
+	return repositories.FindPage(query, page, newestOrdersFirst)
+}
+
+func (r *orderRepository) GetBySellerID(sellerID uint, page models.PageRequest) ([]models.Order, *models.PageInfo, error) {
+	query := r.db.Preload("Items", "seller_id = ?", sellerID).Preload("Items.Pet", withDeleted).
+		Where("id IN (?)", r.db.Model(&models.OrderItem{}).Select("order_id").Where("seller_id = ?", sellerID))
+	return repositories.FindPage(query, page, newestOrdersFirst)
+}
+
+// withDeleted loads pets that were deleted after being ordered, so past
+// orders still show what was bought.
+func withDeleted(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+var newestOrdersFirst = []repositories.SortKey[models.Order]{
+	{Column: "orders.id", Desc: true, Value: func(o *models.Order) any { return o.ID }},
+}
+
+func (r *orderRepository) Transition(id uint, to models.OrderStatus, changedBy *uint, note string) (*models.Order, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return transitionOrder(tx, id, to, changedBy, note)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(id)
+}
+
+// transitionOrder does the work of Transition inside the caller's
+// transaction, so other repositories can combine a status change with their
+// own writes.
+func transitionOrder(tx *gorm.DB, id uint, to models.OrderStatus, changedBy *uint, note string) error {
+	var order models.Order
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&order, id).Error
+	if err != nil {
+		return err
+	}
+	if !order.Status.CanTransitionTo(to) {
+		return repositories.ErrInvalidTransition
+	}
+
+	if err := tx.Model(&order).Update("status", to).Error; err != nil {
+		return err
+	}
+
+	history := models.OrderStatusHistory{
+		OrderID:    order.ID,
+		FromStatus: order.Status,
+		ToStatus:   to,
+		ChangedBy:  changedBy,
+		Note:       note,
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return err
+	}
+
+	var petStatus models.PetStatus
+	switch {
+	case to.ReleasesPets():
+		petStatus = models.PetStatusListed
+	case to.SellsPets():
+		petStatus = models.PetStatusSold
+	default:
+		return nil
+	}
+
+	// Only pets still reserved by the order move; one a seller has since
+	// dealt with some other way keeps its status.
+	itemIDs := make([]uint, len(order.Items))
+	for i, item := range order.Items {
+		itemIDs[i] = item.PetID
+	}
+	var petIDs []uint
+	err = tx.Model(&models.Pet{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ? AND status = ?", itemIDs, models.PetStatusReserved).Pluck("id", &petIDs).Error
+	if err != nil {
+		return err
+	}
+	return repositories.TransitionPets(tx, petIDs, models.PetStatusReserved, petStatus, changedBy, "order "+string(to))
+}
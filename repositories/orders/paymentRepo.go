@@ -0,0 +1,164 @@
+package orders
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"petstore-api/models"
+	"petstore-api/repositories"
+)
+
+type paymentRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepository(db *gorm.DB) repositories.PaymentRepository {
+	return &paymentRepository{db: db}
+}
+
+func (r *paymentRepository) CreateProcessing(payment *models.Payment) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, payment.OrderID).Error
+		if err != nil {
+			return err
+		}
+		if order.Status != models.OrderStatusPending {
+			return repositories.ErrOrderNotPending
+		}
+
+		var active int64
+		err = tx.Model(&models.Payment{}).
+			Where("order_id = ? AND status IN ?", order.ID, []models.PaymentStatus{models.PaymentStatusProcessing, models.PaymentStatusSucceeded}).
+			Count(&active).Error
+		if err != nil {
+			return err
+		}
+		if active > 0 {
+			return repositories.ErrPaymentInProgress
+		}
+
+		payment.Status = models.PaymentStatusProcessing
+		return tx.Create(payment).Error
+	})
+}
+
+func (r *paymentRepository) MarkFailed(id uint) error {
+	return r.db.Model(&models.Payment{}).
+		Where("id = ? AND status = ?", id, models.PaymentStatusProcessing).
+		Update("status", models.PaymentStatusFailed).Error
+}
+
+func (r *paymentRepository) GetByIntentID(intentID string) (*models.Payment, error) {
+	var payment models.Payment
+
+	result := r.db.Where("intent_id = ?", intentID).First(&payment)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &payment, nil
+}
+
+func (r *paymentRepository) GetByOrderID(orderID uint) ([]models.Payment, error) {
+	var payments []models.Payment
+	result := r.db.Where("order_id = ?", orderID).Order("created_at, id").Find(&payments)
+	return payments, result.Error
+}
+
+func (r *paymentRepository) ApplyEvent(event *models.PaymentEvent, status models.PaymentStatus, orderStatus models.OrderStatus) (*models.Payment, bool, bool, error) {
+	var payment models.Payment
+	applied, orderMoved, outOfOrder := false, false, false
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// The event row is the idempotency key: a redelivery hits the
+		// primary key and changes nothing.
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(event)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return tx.Where("intent_id = ?", event.IntentID).First(&payment).Error
+		}
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("intent_id = ?", event.IntentID).First(&payment).Error
+		if err != nil {
+			return err
+		}
+		// An event the payment cannot take, such as a failure reported after
+		// the success, is recorded so it is not delivered again but changes
+		// nothing.
+		if !payment.Status.CanTransitionTo(status) {
+			outOfOrder = true
+			return nil
+		}
+
+		if orderStatus != "" {
+			orderMoved, err = moveOrderForPayment(tx, &payment, status, orderStatus, event.ID)
+			if errors.Is(err, repositories.ErrEventOutOfOrder) {
+				outOfOrder = true
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&payment).Update("status", status).Error; err != nil {
+			return err
+		}
+		applied = true
+		return nil
+	})
+	if err != nil {
+		return nil, false, false, err
+	}
+	if outOfOrder {
+		return &payment, false, false, repositories.ErrEventOutOfOrder
+	}
+
+	return &payment, applied, orderMoved, nil
+}
+
+// moveOrderForPayment moves the payment's order along with a payment event
+// and reports whether it did. A success the order can no longer take is
+// still recorded on the payment so it can be refunded. A refund only moves
+// the order when the payment was what paid for it: refunds of duplicate
+// charges or of money that arrived after a cancellation leave the order
+// alone, and any other refund the order cannot take is out of order.
+func moveOrderForPayment(tx *gorm.DB, payment *models.Payment, status models.PaymentStatus, orderStatus models.OrderStatus, eventID string) (bool, error) {
+	if status == models.PaymentStatusRefunded {
+		var covering int64
+		err := tx.Model(&models.Payment{}).
+			Where("order_id = ? AND id <> ? AND status = ?", payment.OrderID, payment.ID, models.PaymentStatusSucceeded).
+			Count(&covering).Error
+		if err != nil {
+			return false, err
+		}
+		if covering > 0 {
+			return false, nil
+		}
+
+		var order models.Order
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, payment.OrderID).Error
+		if err != nil {
+			return false, err
+		}
+		if order.Status == models.OrderStatusCancelled {
+			return false, nil
+		}
+	}
+
+	err := transitionOrder(tx, payment.OrderID, orderStatus, nil, "payment event "+eventID)
+	if errors.Is(err, repositories.ErrInvalidTransition) {
+		if status == models.PaymentStatusRefunded {
+			return false, repositories.ErrEventOutOfOrder
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
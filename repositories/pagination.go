@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"petstore-api/models"
+)
+
+// SortKey is one column of the order a page is read in. Column is a SQL
+// expression; Value returns the same value from a loaded row so the next
+// page can start right after it.
+type SortKey[T any] struct {
+	Column string
+	Desc   bool
+	Value  func(*T) any
+}
+
+// cursor is what an opaque page cursor decodes to: the sort key values of
+// the last row of the previous page, and the ordering they belong to so a
+// cursor cannot be replayed against a different sort.
+type cursor struct {
+	Order  string            `json:"o"`
+	Values []json.RawMessage `json:"v"`
+}
+
+func orderSignature[T any](keys []SortKey[T]) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = key.Column
+		if key.Desc {
+			parts[i] = "-" + parts[i]
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func encodeCursor[T any](keys []SortKey[T], row *T) (string, error) {
+	c := cursor{Order: orderSignature(keys), Values: make([]json.RawMessage, len(keys))}
+	for i, key := range keys {
+		raw, err := json.Marshal(key.Value(row))
+		if err != nil {
+			return "", err
+		}
+		c.Values[i] = raw
+	}
+
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeCursor returns the cursor's values converted back to the Go types
+// the keys produce, so they bind to SQL parameters exactly.
+func decodeCursor[T any](s string, keys []SortKey[T]) ([]any, error) {
+	var c cursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(raw, &c) != nil {
+		return nil, models.ErrInvalidCursor
+	}
+	if c.Order != orderSignature(keys) || len(c.Values) != len(keys) {
+		return nil, models.ErrInvalidCursor
+	}
+
+	var zero T
+	values := make([]any, len(keys))
+	for i, key := range keys {
+		target := reflect.New(reflect.TypeOf(key.Value(&zero)))
+		if err := json.Unmarshal(c.Values[i], target.Interface()); err != nil {
+			return nil, models.ErrInvalidCursor
+		}
+		values[i] = target.Elem().Interface()
+	}
+	return values, nil
+}
+
+// after builds the keyset condition "comes after these values in this
+// order", spelled out as an OR of prefixes so that keys may mix ascending
+// and descending directions.
+func after[T any](keys []SortKey[T], values []any) (string, []any) {
+	var ors []string
+	var args []any
+	for i, key := range keys {
+		var ands []string
+		for j := 0; j < i; j++ {
+			ands = append(ands, keys[j].Column+" = ?")
+			args = append(args, values[j])
+		}
+		op := " > ?"
+		if key.Desc {
+			op = " < ?"
+		}
+		ands = append(ands, key.Column+op)
+		args = append(args, values[i])
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
+
+// FindPage loads one page of query using keyset pagination over keys, whose
+// last entry must be unique (normally the primary key) so the order is
+// total. Rows are never skipped with OFFSET, so every page costs the same
+// however deep the client goes, and rows inserted meanwhile do not shift
+// page boundaries.
+func FindPage[T any](query *gorm.DB, page models.PageRequest, keys []SortKey[T]) ([]T, *models.PageInfo, error) {
+	limit := page.Size()
+	info := &models.PageInfo{Limit: limit}
+
+	if err := query.Session(&gorm.Session{}).Model(new(T)).Count(&info.Total).Error; err != nil {
+		return nil, nil, err
+	}
+
+	pageQuery := query.Session(&gorm.Session{})
+	if page.Cursor != "" {
+		values, err := decodeCursor(page.Cursor, keys)
+		if err != nil {
+			return nil, nil, err
+		}
+		condition, args := after(keys, values)
+		pageQuery = pageQuery.Where(condition, args...)
+	}
+	for _, key := range keys {
+		if key.Desc {
+			pageQuery = pageQuery.Order(key.Column + " DESC")
+		} else {
+			pageQuery = pageQuery.Order(key.Column)
+		}
+	}
+
+	// One extra row tells whether there is a next page without a second query.
+	var rows []T
+	if err := pageQuery.Limit(limit + 1).Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		next, err := encodeCursor(keys, &rows[limit-1])
+		if err != nil {
+			return nil, nil, err
+		}
+		info.NextCursor = next
+	}
+
+	return rows, info, nil
+}
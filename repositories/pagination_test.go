@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"petstore-api/models"
+)
+
+type pageRow struct {
+	ID        uint
+	Name      string
+	Price     int64
+	CreatedAt time.Time
+}
+
+var (
+	byPriceDesc = []SortKey[pageRow]{
+		{Column: "price", Desc: true, Value: func(r *pageRow) any { return r.Price }},
+		{Column: "id", Value: func(r *pageRow) any { return r.ID }},
+	}
+	byNameAndCreated = []SortKey[pageRow]{
+		{Column: "name", Value: func(r *pageRow) any { return r.Name }},
+		{Column: "created_at", Desc: true, Value: func(r *pageRow) any { return r.CreatedAt }},
+		{Column: "id", Value: func(r *pageRow) any { return r.ID }},
+	}
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 30, 15, 123456789, time.UTC)
+	row := &pageRow{ID: 42, Name: "Rex \"the\" dog", Price: 1999, CreatedAt: created}
+
+	tests := []struct {
+		name string
+		keys []SortKey[pageRow]
+		want []any
+	}{
+		{"integers", byPriceDesc, []any{int64(1999), uint(42)}},
+		{"strings and times", byNameAndCreated, []any{"Rex \"the\" dog", created, uint(42)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := encodeCursor(tt.keys, row)
+			if err != nil {
+				t.Fatalf("encodeCursor: %v", err)
+			}
+			values, err := decodeCursor(encoded, tt.keys)
+			if err != nil {
+				t.Fatalf("decodeCursor: %v", err)
+			}
+			if len(values) != len(tt.want) {
+				t.Fatalf("decodeCursor returned %d values, want %d", len(values), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if wantTime, ok := want.(time.Time); ok {
+					if got, ok := values[i].(time.Time); !ok || !got.Equal(wantTime) {
+						t.Errorf("value %d = %#v, want %v", i, values[i], wantTime)
+					}
+					continue
+				}
+				if !reflect.DeepEqual(values[i], want) {
+					t.Errorf("value %d = %#v (%T), want %#v (%T)", i, values[i], values[i], want, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	row := &pageRow{ID: 42, Name: "Rex", Price: 1999}
+	priceCursor, err := encodeCursor(byPriceDesc, row)
+	if err != nil {
+		t.Fatalf("encodeCursor: %v", err)
+	}
+	ascending := []SortKey[pageRow]{
+		{Column: "price", Value: byPriceDesc[0].Value},
+		{Column: "id", Value: byPriceDesc[1].Value},
+	}
+	onlyID := byPriceDesc[1:]
+
+	tests := []struct {
+		name   string
+		cursor string
+		keys   []SortKey[pageRow]
+	}{
+		{"different columns", priceCursor, byNameAndCreated},
+		{"same columns in another direction", priceCursor, ascending},
+		{"fewer keys", priceCursor, onlyID},
+		{"not base64", "!!!", byPriceDesc},
+		{"not JSON", base64.RawURLEncoding.EncodeToString([]byte("price")), byPriceDesc},
+		{"value count does not match", encode(`{"o":"-price,id","v":[1999]}`), byPriceDesc},
+		{"value of the wrong type", encode(`{"o":"-price,id","v":["cheap",42]}`), byPriceDesc},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeCursor(tt.cursor, tt.keys); !errors.Is(err, models.ErrInvalidCursor) {
+				t.Errorf("decodeCursor error = %v, want %v", err, models.ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestAfter(t *testing.T) {
+	condition, args := after(byPriceDesc, []any{int64(1999), uint(42)})
+
+	wantCondition := "((price < ?) OR (price = ? AND id > ?))"
+	if condition != wantCondition {
+		t.Errorf("condition = %q, want %q", condition, wantCondition)
+	}
+	wantArgs := []any{int64(1999), int64(1999), uint(42)}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %#v, want %#v", args, wantArgs)
+	}
+}
+
+func encode(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
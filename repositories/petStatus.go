@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"petstore-api/models"
+)
+
+// TransitionPets moves pets from one status to another inside the caller's
+// transaction and records the change in their history. It fails with
+// ErrPetUnavailable when any of the pets is not in the from status.
+func TransitionPets(tx *gorm.DB, petIDs []uint, from, to models.PetStatus, changedBy *uint, note string) error {
+	if len(petIDs) == 0 {
+		return nil
+	}
+
+	result := tx.Model(&models.Pet{}).Where("id IN ? AND status = ?", petIDs, from).
+		Updates(map[string]interface{}{"status": to, "status_changed_at": time.Now(), "version": gorm.Expr("version + 1")})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != int64(len(petIDs)) {
+		return ErrPetUnavailable
+	}
+
+	history := make([]models.PetStatusHistory, len(petIDs))
+	for i, id := range petIDs {
+		history[i] = models.PetStatusHistory{PetID: id, FromStatus: from, ToStatus: to, ChangedBy: changedBy, Note: note}
+	}
+	return tx.Create(&history).Error
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"petstore-api/models"
 	"time"
 )
@@ -13,21 +14,23 @@ type bucketRepo struct {
 	collection *mongo.Collection
 }
 
-func NewBucketRepository(client *mongo.Client) *bucketRepo {
-	collection := client.Database("buckets").Collection("buckets")
+func NewBucketRepository(db *mongo.Database) *bucketRepo {
+	collection := db.Collection("buckets")
 	return &bucketRepo{
 		collection: collection,
 	}
 }
 
+// AddPet puts a pet into the user's cart, creating the cart document on the
+// first add. A pet that is already in the cart is not added twice.
 func (b *bucketRepo) AddPet(userID uint, itemID uint) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	filter := bson.M{"userId": userID}
-	update := bson.M{"$push": bson.M{"pets": itemID}}
+	update := bson.M{"$addToSet": bson.M{"pets": itemID}}
 
-	_, err := b.collection.UpdateOne(ctx, filter, update)
+	_, err := b.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
 	return err
 }
 
@@ -42,7 +45,7 @@ func (b *bucketRepo) RemovePet(userID uint, itemID uint) error {
 	return err
 }
 
-func (b *bucketRepo) GetPetsByBuyerID(userID uint) ([]models.Pet, error) {
+func (b *bucketRepo) GetPetIDs(userID uint) ([]uint, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -51,10 +54,18 @@ func (b *bucketRepo) GetPetsByBuyerID(userID uint) ([]models.Pet, error) {
 	err := b.collection.FindOne(ctx, bson.M{"userId": userID}).Decode(&result)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return []models.Pet{}, nil
+			return []uint{}, nil
 		}
 		return nil, err
 	}
 
-	return result.Pets, nil
+	return result.PetIDs, nil
+}
+
+func (b *bucketRepo) Clear(userID uint) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := b.collection.DeleteOne(ctx, bson.M{"userId": userID})
+	return err
 }
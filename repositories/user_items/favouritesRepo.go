@@ -5,6 +5,7 @@ import (
 	"errors"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"petstore-api/models"
 	"time"
 )
@@ -13,21 +14,23 @@ type favouriteRepo struct {
 	collection *mongo.Collection
 }
 
-func NewFavouriteRepository(client *mongo.Client) *favouriteRepo {
-	collection := client.Database("buckets").Collection("buckets")
+func NewFavouriteRepository(db *mongo.Database) *favouriteRepo {
+	collection := db.Collection("favourites")
 	return &favouriteRepo{
 		collection: collection,
 	}
 }
 
+// AddPet favourites a pet, creating the user's document on the first add.
+// Favouriting the same pet again leaves the list unchanged.
 func (b *favouriteRepo) AddPet(userID uint, itemID uint) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	filter := bson.M{"userId": userID}
-	update := bson.M{"$push": bson.M{"pets": itemID}}
+	update := bson.M{"$addToSet": bson.M{"pets": itemID}}
 
-	_, err := b.collection.UpdateOne(ctx, filter, update)
+	_, err := b.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
 	return err
 }
 
@@ -42,19 +45,63 @@ func (b *favouriteRepo) RemovePet(userID uint, itemID uint) error {
 	return err
 }
 
-func (b *favouriteRepo) GetPetsByBuyerID(userID uint) ([]models.Pet, error) {
+func (b *favouriteRepo) GetPetIDs(userID uint) ([]uint, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	var result models.Bucket
+	var result models.Favourite
 
 	err := b.collection.FindOne(ctx, bson.M{"userId": userID}).Decode(&result)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return []models.Pet{}, nil
+			return []uint{}, nil
 		}
 		return nil, err
 	}
 
-	return result.Pets, nil
+	return result.PetIDs, nil
+}
+
+func (b *favouriteRepo) Clear(userID uint) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := b.collection.DeleteOne(ctx, bson.M{"userId": userID})
+	return err
+}
+
+func (b *favouriteRepo) CountByPetIDs(petIDs []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64)
+	if len(petIDs) == 0 {
+		return counts, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"pets": bson.M{"$in": petIDs}}}},
+		{{Key: "$unwind", Value: "$pets"}},
+		{{Key: "$match", Value: bson.M{"pets": bson.M{"$in": petIDs}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$pets", "count": bson.M{"$sum": 1}}}},
+	}
+
+	cursor, err := b.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var row struct {
+			PetID uint  `bson:"_id"`
+			Count int64 `bson:"count"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return nil, err
+		}
+		counts[row.PetID] = row.Count
+	}
+
+	return counts, cursor.Err()
 }
@@ -0,0 +1,47 @@
+package user_items
+
+import (
+	"gorm.io/gorm"
+	"petstore-api/models"
+	"petstore-api/repositories"
+)
+
+type healthRecordRepository struct {
+	db *gorm.DB
+}
+
+func NewHealthRecordRepository(db *gorm.DB) repositories.HealthRecordRepository {
+	return &healthRecordRepository{db: db}
+}
+
+func (r *healthRecordRepository) Create(record *models.HealthRecord) error {
+	result := r.db.Create(record)
+	return result.Error
+}
+
+func (r *healthRecordRepository) GetByID(id uint) (*models.HealthRecord, error) {
+	var record models.HealthRecord
+
+	result := r.db.First(&record, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &record, nil
+}
+
+func (r *healthRecordRepository) GetByPetID(petID uint) ([]models.HealthRecord, error) {
+	var records []models.HealthRecord
+	result := r.db.Where("pet_id = ?", petID).Order("date DESC, id DESC").Find(&records)
+	return records, result.Error
+}
+
+func (r *healthRecordRepository) Update(record *models.HealthRecord) error {
+	result := r.db.Omit("Pet").Save(record)
+	return result.Error
+}
+
+func (r *healthRecordRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.HealthRecord{}, id)
+	return result.Error
+}
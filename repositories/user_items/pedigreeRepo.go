@@ -0,0 +1,175 @@
+package user_items
+
+import (
+	"database/sql"
+	"strings"
+
+	"gorm.io/gorm"
+	"petstore-api/models"
+	"petstore-api/repositories"
+)
+
+// pedigreeLockKey serializes parent assignments, so two concurrent requests
+// cannot each pass the cycle check and together close a loop.
+const pedigreeLockKey = 0x7065646967726565
+
+// ancestorsSQL lists a pet and all of its pet ancestors. UNION drops rows it
+// has seen before, so the recursion ends even if the data held a cycle.
+const ancestorsSQL = `
+WITH RECURSIVE ancestors(id) AS (
+	SELECT CAST(@parent AS bigint)
+	UNION
+	SELECT parent.id
+	FROM pets
+	JOIN ancestors ON pets.id = ancestors.id
+	CROSS JOIN LATERAL (VALUES (pets.sire_id), (pets.dam_id)) AS parent(id)
+	WHERE parent.id IS NOT NULL
+)
+SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = @pet)`
+
+// pedigreeSQL walks up from a pet through pets and pedigree entries alike.
+// Each step reads only the parents of the nodes it has reached, and deleted
+// pets are left out together with their ancestry. Each row carries the path
+// of nodes from the root, which keeps a node from being visited twice on one
+// branch and tells the caller where it belongs.
+const pedigreeSQL = `
+WITH RECURSIVE tree(kind, id, role, depth, path) AS (
+	SELECT CAST('pet' AS text), id, CAST('' AS text), 0, ARRAY['pet:' || id] FROM pets WHERE id = @pet AND deleted_at IS NULL
+	UNION ALL
+	SELECT p.kind, p.id, p.role, tree.depth + 1, tree.path || (p.kind || ':' || p.id)
+	FROM tree
+	LEFT JOIN pets ON tree.kind = 'pet' AND pets.id = tree.id
+	LEFT JOIN pedigree_entries e ON tree.kind = 'pedigree_entry' AND e.id = tree.id
+	CROSS JOIN LATERAL (VALUES
+		(CAST('pet' AS text), pets.sire_id, CAST('sire' AS text)),
+		('pet', pets.dam_id, 'dam'),
+		('pedigree_entry', pets.sire_entry_id, 'sire'),
+		('pedigree_entry', pets.dam_entry_id, 'dam'),
+		('pedigree_entry', e.sire_id, 'sire'),
+		('pedigree_entry', e.dam_id, 'dam')
+	) AS p(kind, id, role)
+	WHERE p.id IS NOT NULL
+		AND tree.depth < @generations
+		AND NOT (p.kind || ':' || p.id) = ANY(tree.path)
+		AND (p.kind <> 'pet' OR EXISTS (SELECT 1 FROM pets parent WHERE parent.id = p.id AND parent.deleted_at IS NULL))
+)
+SELECT tree.kind, tree.id, tree.role, array_to_string(tree.path, '/') AS path,
+	COALESCE(pets.name, e.name) AS name,
+	COALESCE(pets.species, e.species) AS species,
+	COALESCE(pets.breed, e.breed) AS breed,
+	COALESCE(e.registration_number, '') AS registration_number
+FROM tree
+LEFT JOIN pets ON tree.kind = 'pet' AND pets.id = tree.id
+LEFT JOIN pedigree_entries e ON tree.kind = 'pedigree_entry' AND e.id = tree.id
+ORDER BY tree.depth, tree.path`
+
+type pedigreeRepository struct {
+	db *gorm.DB
+}
+
+func NewPedigreeRepository(db *gorm.DB) repositories.PedigreeRepository {
+	return &pedigreeRepository{db: db}
+}
+
+func (r *pedigreeRepository) CreateEntry(entry *models.PedigreeEntry) error {
+	result := r.db.Create(entry)
+	return result.Error
+}
+
+func (r *pedigreeRepository) GetEntryByID(id uint) (*models.PedigreeEntry, error) {
+	var entry models.PedigreeEntry
+
+	result := r.db.First(&entry, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &entry, nil
+}
+
+func (r *pedigreeRepository) SetParents(pet *models.Pet) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", pedigreeLockKey).Error; err != nil {
+			return err
+		}
+
+		for _, parentID := range []*uint{pet.SireID, pet.DamID} {
+			if parentID == nil {
+				continue
+			}
+			var cycle bool
+			err := tx.Raw(ancestorsSQL, sql.Named("parent", *parentID), sql.Named("pet", pet.ID)).Scan(&cycle).Error
+			if err != nil {
+				return err
+			}
+			if cycle {
+				return repositories.ErrPedigreeCycle
+			}
+		}
+
+		result := tx.Model(&models.Pet{}).Where("id = ? AND version = ?", pet.ID, pet.Version).Updates(map[string]interface{}{
+			"sire_id":       pet.SireID,
+			"dam_id":        pet.DamID,
+			"sire_entry_id": pet.SireEntryID,
+			"dam_entry_id":  pet.DamEntryID,
+			"version":       gorm.Expr("version + 1"),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return repositories.ErrVersionConflict
+		}
+		pet.Version++
+		return nil
+	})
+}
+
+type pedigreeRow struct {
+	Kind               string
+	ID                 uint
+	Role               string
+	Path               string
+	Name               string
+	Species            string
+	Breed              string
+	RegistrationNumber string
+}
+
+func (r *pedigreeRepository) Ancestry(petID uint, generations int) (*models.PedigreeNode, error) {
+	var rows []pedigreeRow
+	err := r.db.Raw(pedigreeSQL, sql.Named("pet", petID), sql.Named("generations", generations)).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	// Rows come ordered by depth, so a node's child is always built first.
+	nodes := make(map[string]*models.PedigreeNode, len(rows))
+	for _, row := range rows {
+		node := &models.PedigreeNode{
+			Kind:               row.Kind,
+			ID:                 row.ID,
+			Name:               row.Name,
+			Species:            row.Species,
+			Breed:              row.Breed,
+			RegistrationNumber: row.RegistrationNumber,
+		}
+		nodes[row.Path] = node
+
+		cut := strings.LastIndex(row.Path, "/")
+		if cut < 0 {
+			continue
+		}
+		child := nodes[row.Path[:cut]]
+		if row.Role == "sire" {
+			child.Sire = node
+		} else {
+			child.Dam = node
+		}
+	}
+
+	return nodes[rows[0].Path], nil
+}
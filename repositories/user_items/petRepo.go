@@ -1,7 +1,14 @@
 package user_items
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"petstore-api/models"
 	"petstore-api/repositories"
 )
@@ -14,20 +21,137 @@ func NewPetRepository(db *gorm.DB) repositories.PetRepository {
 	return &petRepository{db: db}
 }
 
-func (r *petRepository) GetAll(includeSeller bool, sellerID *uint) ([]models.Pet, error) {
-	var pets []models.Pet
-	query := r.db
+func (r *petRepository) GetAll(includeSeller bool, filter models.PetFilter, page models.PageRequest) ([]models.Pet, *models.PageInfo, error) {
+	query := applyPetFilter(r.db, filter)
 
 	if includeSeller {
 		query = query.Preload("Seller")
 	}
 
-	if sellerID != nil {
-		query = query.Where("seller_id = ?", *sellerID)
+	keys, err := petSortKeys(page.Sort)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	result := query.Find(&pets)
-	return pets, result.Error
+	return repositories.FindPage(query, page, keys)
+}
+
+func (r *petRepository) Search(text string, filter models.PetFilter, page models.PageRequest) ([]models.PetSearchResult, *models.PageInfo, error) {
+	q := sql.Named("q", text)
+	matches := applyPetFilter(r.db.Model(&models.Pet{}), filter).
+		Select(`pets.*,
+			ts_rank(pets.search_vector, websearch_to_tsquery('english', @q)) AS rank,
+			ts_headline('english', COALESCE(pets.description, ''), websearch_to_tsquery('english', @q),
+				'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MinWords=10, MaxWords=30') AS snippet`, q).
+		Where("pets.search_vector @@ websearch_to_tsquery('english', @q)", q)
+
+	// Ranking in a subquery lets the keyset cursor refer to rank like any
+	// other column.
+	return repositories.FindPage(r.db.Table("(?) AS pets", matches), page, []repositories.SortKey[models.PetSearchResult]{
+		{Column: "pets.rank", Desc: true, Value: func(p *models.PetSearchResult) any { return p.Rank }},
+		{Column: "pets.id", Value: func(p *models.PetSearchResult) any { return p.ID }},
+	})
+}
+
+func (r *petRepository) Facets(filter models.PetFilter, options models.PetFacetOptions) (*models.PetFacets, error) {
+	matching := applyPetFilter(r.db.Model(&models.Pet{}), filter)
+	pets := func() *gorm.DB {
+		return r.db.Table("(?) AS pets", matching)
+	}
+	facets := &models.PetFacets{}
+
+	if err := pets().Count(&facets.Total).Error; err != nil {
+		return nil, err
+	}
+
+	err := pets().Select("species AS value, COUNT(*) AS count").
+		Group("species").Order("count DESC, value").Scan(&facets.Species).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = pets().Select("breed AS value, COUNT(*) AS count").Where("breed <> ''").
+		Group("breed").Order("count DESC, value").Scan(&facets.Breeds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	statusPets := pets()
+	if len(options.StatusScope) > 0 {
+		statusFilter := filter
+		statusFilter.Statuses = options.StatusScope
+		statusPets = r.db.Table("(?) AS pets", applyPetFilter(r.db.Model(&models.Pet{}), statusFilter))
+	}
+	err = statusPets.Select("status, COUNT(*) AS count").
+		Group("status").Order("count DESC, status").Scan(&facets.Statuses).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = pets().Select("pets.seller_id, users.name AS seller_name, COUNT(*) AS count").
+		Joins("JOIN users ON users.id = pets.seller_id").
+		Group("pets.seller_id, users.name").Order("count DESC, pets.seller_id").Scan(&facets.Sellers).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(options.PriceBounds) > 0 {
+		bounds := make([]int64, len(options.PriceBounds))
+		for i, bound := range options.PriceBounds {
+			bounds[i] = bound.Amount
+		}
+		currency := options.PriceBounds[0].Currency
+		counts, err := bucketCounts(pets().Where("price_currency = ?", currency), "price_amount", bounds)
+		if err != nil {
+			return nil, err
+		}
+		for i, bound := range options.PriceBounds {
+			bucket := models.PriceBucketCount{Min: bound, Count: counts[i]}
+			if i+1 < len(options.PriceBounds) {
+				bucket.Max = &options.PriceBounds[i+1]
+			}
+			facets.Prices = append(facets.Prices, bucket)
+		}
+	}
+
+	if len(options.AgeBounds) > 0 {
+		bounds := make([]int64, len(options.AgeBounds))
+		for i, bound := range options.AgeBounds {
+			bounds[i] = int64(bound)
+		}
+		counts, err := bucketCounts(pets(), "age", bounds)
+		if err != nil {
+			return nil, err
+		}
+		for i, bound := range options.AgeBounds {
+			bucket := models.AgeBucketCount{Min: bound, Count: counts[i]}
+			if i+1 < len(options.AgeBounds) {
+				bucket.Max = &options.AgeBounds[i+1]
+			}
+			facets.Ages = append(facets.Ages, bucket)
+		}
+	}
+
+	return facets, nil
+}
+
+func (r *petRepository) Export(filter models.PetFilter, fn func(pet *models.Pet) error) error {
+	rows, err := applyPetFilter(r.db.Model(&models.Pet{}), filter).Order("pets.id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pet models.Pet
+		if err := r.db.ScanRows(rows, &pet); err != nil {
+			return err
+		}
+		if err := fn(&pet); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
 }
 
 func (r *petRepository) GetByID(id uint, includeSeller bool) (*models.Pet, error) {
@@ -46,18 +170,193 @@ func (r *petRepository) GetByID(id uint, includeSeller bool) (*models.Pet, error
 	return &pet, nil
 }
 
-func (r *petRepository) Create(pet *models.Pet) error {
-	result := r.db.Create(pet)
-	return result.Error
+func (r *petRepository) GetByIDs(ids []uint) ([]models.Pet, error) {
+	var pets []models.Pet
+	if len(ids) == 0 {
+		return pets, nil
+	}
+
+	result := r.db.Where("id IN ?", ids).Find(&pets)
+	return pets, result.Error
+}
+
+// Create stores the pet together with the first entry of its status
+// history.
+func (r *petRepository) Create(pet *models.Pet, changedBy *uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		pet.StatusChangedAt = time.Now()
+		if err := tx.Create(pet).Error; err != nil {
+			return err
+		}
+		history := models.PetStatusHistory{PetID: pet.ID, ToStatus: pet.Status, ChangedBy: changedBy}
+		return tx.Create(&history).Error
+	})
+}
+
+// petBatchSize is how many pets CreateMany inserts per statement.
+const petBatchSize = 100
+
+var errRollBackImport = errors.New("roll back import")
+
+// CreateMany inserts each batch under its own savepoint. When a batch fails
+// its pets are retried one at a time, so the pets at fault can be reported
+// and, with partial set, the rest of the batch still stored.
+func (r *petRepository) CreateMany(pets []models.Pet, changedBy *uint, partial bool) (map[int]error, error) {
+	failed := make(map[int]error)
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(pets); start += petBatchSize {
+			batch := pets[start:min(start+petBatchSize, len(pets))]
+			err := tx.Transaction(func(tx *gorm.DB) error {
+				return createPets(tx, batch, changedBy)
+			})
+			if err == nil {
+				continue
+			}
+			clearPetIDs(batch)
+
+			for i := range batch {
+				pet := batch[i : i+1]
+				err := tx.Transaction(func(tx *gorm.DB) error {
+					return createPets(tx, pet, changedBy)
+				})
+				if err != nil {
+					clearPetIDs(pet)
+					failed[start+i] = err
+				}
+			}
+		}
+
+		if len(failed) > 0 && !partial {
+			return errRollBackImport
+		}
+		return nil
+	})
+	if errors.Is(err, errRollBackImport) {
+		clearPetIDs(pets)
+		return failed, nil
+	}
+	if err != nil {
+		clearPetIDs(pets)
+		return nil, err
+	}
+
+	return failed, nil
+}
+
+func createPets(tx *gorm.DB, pets []models.Pet, changedBy *uint) error {
+	now := time.Now()
+	for i := range pets {
+		pets[i].StatusChangedAt = now
+	}
+	if err := tx.Create(&pets).Error; err != nil {
+		return err
+	}
+
+	history := make([]models.PetStatusHistory, len(pets))
+	for i, pet := range pets {
+		history[i] = models.PetStatusHistory{PetID: pet.ID, ToStatus: pet.Status, ChangedBy: changedBy}
+	}
+	return tx.Create(&history).Error
+}
+
+func clearPetIDs(pets []models.Pet) {
+	for i := range pets {
+		pets[i].ID = 0
+	}
 }
 
+// Update saves everything but the status, which only Transition and orders
+// change, and the parents, which are only written by
+// PedigreeRepository.SetParents after its cycle check. The pet is only
+// written if it is still at pet.Version, and then moves to the next version;
+// otherwise Update fails with ErrVersionConflict.
 func (r *petRepository) Update(pet *models.Pet) error {
-	result := r.db.Save(pet)
+	version := pet.Version
+	pet.Version++
+
+	result := r.db.Model(pet).Where("version = ?", version).Select("*").
+		Omit(clause.Associations, "ID", "CreatedAt", "DeletedAt", "Status", "StatusChangedAt", "SireID", "DamID", "SireEntryID", "DamEntryID").
+		Updates(pet)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = repositories.ErrVersionConflict
+	}
+	if result.Error != nil {
+		pet.Version = version
+	}
 	return result.Error
 }
 
+func (r *petRepository) Transition(id uint, version int, to models.PetStatus, changedBy *uint, note string) (*models.Pet, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var pet models.Pet
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "status", "version").First(&pet, id).Error; err != nil {
+			return err
+		}
+		if pet.Version != version {
+			return repositories.ErrVersionConflict
+		}
+		if !pet.Status.CanTransitionTo(to) {
+			return repositories.ErrInvalidTransition
+		}
+		return repositories.TransitionPets(tx, []uint{id}, pet.Status, to, changedBy, note)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(id, false)
+}
+
+func (r *petRepository) GetStatusHistory(id uint) ([]models.PetStatusHistory, error) {
+	var history []models.PetStatusHistory
+	result := r.db.Where("pet_id = ?", id).Order("created_at, id").Find(&history)
+	return history, result.Error
+}
+
 func (r *petRepository) Delete(id uint) error {
-	result := r.db.Delete(&models.Pet{}, id)
+	result := r.db.Where("status <> ?", models.PetStatusReserved).Delete(&models.Pet{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repositories.ErrPetReserved
+	}
+	return nil
+}
+
+// Unscoped shares the connection but drops the soft-delete condition from
+// every query.
+func (r *petRepository) Unscoped() repositories.PetRepository {
+	return &petRepository{db: r.db.Unscoped().Session(&gorm.Session{})}
+}
+
+func (r *petRepository) Restore(id uint) error {
+	result := r.db.Unscoped().Model(&models.Pet{}).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *petRepository) GetPurgeable(cutoff time.Time, limit int) ([]uint, error) {
+	var ids []uint
+	result := r.db.Unscoped().Model(&models.Pet{}).
+		Where("deleted_at < ?", cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM order_items WHERE order_items.pet_id = pets.id)").
+		Order("deleted_at").Limit(limit).Pluck("id", &ids)
+	return ids, result.Error
+}
+
+// Purge relies on the foreign keys to take the photos, health records and
+// status history rows along and to unlink the pets as parents of others.
+func (r *petRepository) Purge(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	result := r.db.Unscoped().Delete(&models.Pet{}, ids)
 	return result.Error
 }
 
@@ -66,3 +365,122 @@ func (r *petRepository) GetBySellerID(sellerID uint) ([]models.Pet, error) {
 	result := r.db.Where("seller_id = ?", sellerID).Find(&pets)
 	return pets, result.Error
 }
+
+func applyPetFilter(query *gorm.DB, f models.PetFilter) *gorm.DB {
+	if f.SellerID != nil {
+		query = query.Where("seller_id = ?", *f.SellerID)
+	}
+	if len(f.Species) > 0 {
+		query = query.Where("LOWER(species) IN ?", lowerAll(f.Species))
+	}
+	if len(f.Breeds) > 0 {
+		query = query.Where("LOWER(breed) IN ?", lowerAll(f.Breeds))
+	}
+	if f.Currency != "" {
+		query = query.Where("price_currency = ?", f.Currency)
+	}
+	if f.MinPrice != nil {
+		query = query.Where("price_amount >= ?", f.MinPrice.Amount)
+	}
+	if f.MaxPrice != nil {
+		query = query.Where("price_amount <= ?", f.MaxPrice.Amount)
+	}
+	if f.MinAge != nil {
+		query = query.Where("age >= ?", *f.MinAge)
+	}
+	if f.MaxAge != nil {
+		query = query.Where("age <= ?", *f.MaxAge)
+	}
+	if len(f.Statuses) > 0 {
+		query = query.Where("status IN ?", f.Statuses)
+	}
+	if f.Vaccinated != nil {
+		current := "EXISTS (SELECT 1 FROM health_records hr WHERE hr.pet_id = pets.id AND hr.type = ? AND (hr.next_due_date IS NULL OR hr.next_due_date >= CURRENT_DATE))"
+		if !*f.Vaccinated {
+			current = "NOT " + current
+		}
+		query = query.Where(current, models.HealthRecordVaccine)
+	}
+	if f.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *f.CreatedAfter)
+	}
+	if f.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *f.CreatedBefore)
+	}
+	return query
+}
+
+func lowerAll(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, v := range values {
+		lowered[i] = strings.ToLower(v)
+	}
+	return lowered
+}
+
+// petSortColumns maps each of models.PetSortFields to the columns it orders
+// by. Prices are grouped by currency first, since amounts in different
+// currencies do not compare.
+var petSortColumns = map[string][]repositories.SortKey[models.Pet]{
+	"name":    {{Column: "pets.name", Value: func(p *models.Pet) any { return p.Name }}},
+	"species": {{Column: "pets.species", Value: func(p *models.Pet) any { return p.Species }}},
+	"breed":   {{Column: "COALESCE(pets.breed, '')", Value: func(p *models.Pet) any { return p.Breed }}},
+	"age":     {{Column: "pets.age", Value: func(p *models.Pet) any { return p.Age }}},
+	"price": {
+		{Column: "pets.price_currency", Value: func(p *models.Pet) any { return p.Price.Currency }},
+		{Column: "pets.price_amount", Value: func(p *models.Pet) any { return p.Price.Amount }},
+	},
+	"created_at": {{Column: "pets.created_at", Value: func(p *models.Pet) any { return p.CreatedAt }}},
+	"updated_at": {{Column: "pets.updated_at", Value: func(p *models.Pet) any { return p.UpdatedAt }}},
+}
+
+// petSortKeys turns the requested sort into keyset columns, ending with the
+// primary key so rows with equal sort values still have a fixed order.
+func petSortKeys(sort []models.SortField) ([]repositories.SortKey[models.Pet], error) {
+	var keys []repositories.SortKey[models.Pet]
+	for _, field := range sort {
+		columns, ok := petSortColumns[field.Field]
+		if !ok {
+			return nil, fmt.Errorf("unknown sort field %q", field.Field)
+		}
+		for _, column := range columns {
+			column.Desc = field.Desc
+			keys = append(keys, column)
+		}
+	}
+
+	return append(keys, repositories.SortKey[models.Pet]{
+		Column: "pets.id",
+		Value:  func(p *models.Pet) any { return p.ID },
+	}), nil
+}
+
+// bucketCounts counts rows per bucket in a single grouped query. Bucket i
+// holds values of column from bounds[i] up to bounds[i+1]; values below
+// bounds[0] are left out.
+func bucketCounts(query *gorm.DB, column string, bounds []int64) ([]int64, error) {
+	var cases []string
+	var args []any
+	for i := len(bounds) - 1; i >= 0; i-- {
+		cases = append(cases, fmt.Sprintf("WHEN %s >= ? THEN %d", column, i))
+		args = append(args, bounds[i])
+	}
+
+	var rows []struct {
+		Bucket *int
+		Count  int64
+	}
+	err := query.Select("CASE "+strings.Join(cases, " ")+" END AS bucket, COUNT(*) AS count", args...).
+		Group("bucket").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]int64, len(bounds))
+	for _, row := range rows {
+		if row.Bucket != nil {
+			counts[*row.Bucket] = row.Count
+		}
+	}
+	return counts, nil
+}
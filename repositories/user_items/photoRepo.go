@@ -0,0 +1,135 @@
+package user_items
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"petstore-api/models"
+	"petstore-api/repositories"
+)
+
+type photoRepository struct {
+	db *gorm.DB
+}
+
+func NewPhotoRepository(db *gorm.DB) repositories.PhotoRepository {
+	return &photoRepository{db: db}
+}
+
+// Create appends the photo after the pet's existing photos. The pet row is
+// locked so concurrent uploads get distinct positions and only the first
+// photo of a pet becomes primary.
+func (r *photoRepository) Create(photo *models.PetPhoto) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var pet models.Pet
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&pet, photo.PetID).Error; err != nil {
+			return err
+		}
+
+		var last struct {
+			Count    int64
+			Position int
+		}
+		err := tx.Model(&models.PetPhoto{}).Select("COUNT(*) AS count, COALESCE(MAX(position), -1) AS position").
+			Where("pet_id = ?", photo.PetID).Scan(&last).Error
+		if err != nil {
+			return err
+		}
+
+		photo.Position = last.Position + 1
+		photo.IsPrimary = last.Count == 0
+		return tx.Create(photo).Error
+	})
+}
+
+func (r *photoRepository) GetByID(id uint) (*models.PetPhoto, error) {
+	var photo models.PetPhoto
+
+	result := r.db.First(&photo, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &photo, nil
+}
+
+func (r *photoRepository) GetByPetIDs(petIDs []uint) ([]models.PetPhoto, error) {
+	var photos []models.PetPhoto
+	if len(petIDs) == 0 {
+		return photos, nil
+	}
+
+	result := r.db.Where("pet_id IN ?", petIDs).Order("pet_id, position, id").Find(&photos)
+	return photos, result.Error
+}
+
+// Delete removes a photo and, when it was the primary one, promotes the
+// first remaining photo of the pet.
+func (r *photoRepository) Delete(photo *models.PetPhoto) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.PetPhoto{}, photo.ID).Error; err != nil {
+			return err
+		}
+		if !photo.IsPrimary {
+			return nil
+		}
+
+		var next models.PetPhoto
+		err := tx.Where("pet_id = ?", photo.PetID).Order("position, id").Limit(1).Find(&next).Error
+		if err != nil || next.ID == 0 {
+			return err
+		}
+		return tx.Model(&next).Update("is_primary", true).Error
+	})
+}
+
+// Reorder sets the positions of a pet's photos to the order of photoIDs,
+// which must list every photo of the pet exactly once.
+func (r *photoRepository) Reorder(petID uint, photoIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var existing []uint
+		err := tx.Model(&models.PetPhoto{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("pet_id = ?", petID).Pluck("id", &existing).Error
+		if err != nil {
+			return err
+		}
+		if !sameIDs(existing, photoIDs) {
+			return repositories.ErrPhotoSetMismatch
+		}
+
+		for position, id := range photoIDs {
+			if err := tx.Model(&models.PetPhoto{}).Where("id = ?", id).Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *photoRepository) SetPrimary(photo *models.PetPhoto) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.PetPhoto{}).Where("pet_id = ? AND id <> ?", photo.PetID, photo.ID).
+			Update("is_primary", false).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.PetPhoto{}).Where("id = ?", photo.ID).Update("is_primary", true).Error
+	})
+}
+
+// sameIDs reports whether b holds exactly the IDs of a, each once.
+func sameIDs(a, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[uint]bool, len(a))
+	for _, id := range a {
+		seen[id] = true
+	}
+	for _, id := range b {
+		if !seen[id] {
+			return false
+		}
+		delete(seen, id)
+	}
+	return true
+}
@@ -0,0 +1,160 @@
+package user_items
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"petstore-api/models"
+	"petstore-api/repositories"
+)
+
+type taxonomyRepository struct {
+	db *gorm.DB
+}
+
+func NewTaxonomyRepository(db *gorm.DB) repositories.TaxonomyRepository {
+	return &taxonomyRepository{db: db}
+}
+
+func (r *taxonomyRepository) GetAllSpecies() ([]models.Species, error) {
+	var species []models.Species
+	result := r.db.Preload("Aliases", orderByName).Preload("Breeds", orderByName).Preload("Breeds.Aliases", orderByName).
+		Order("name").Find(&species)
+	return species, result.Error
+}
+
+func (r *taxonomyRepository) GetSpeciesByID(id uint) (*models.Species, error) {
+	var species models.Species
+
+	result := r.db.Preload("Aliases", orderByName).Preload("Breeds", orderByName).Preload("Breeds.Aliases", orderByName).
+		First(&species, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &species, nil
+}
+
+func (r *taxonomyRepository) FindSpecies(name string) (*models.Species, error) {
+	var species models.Species
+
+	result := r.db.Where("lower(name) = lower(?)", name).
+		Or("id IN (?)", r.db.Model(&models.SpeciesAlias{}).Select("species_id").Where("lower(name) = lower(?)", name)).
+		First(&species)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &species, nil
+}
+
+func (r *taxonomyRepository) CreateSpecies(species *models.Species) error {
+	result := r.db.Create(species)
+	return result.Error
+}
+
+// UpdateSpecies replaces the species' aliases and, when the name changed,
+// renames the species on pets too.
+func (r *taxonomyRepository) UpdateSpecies(species *models.Species, previousName string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Aliases", "Breeds").Save(species).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("species_id = ?", species.ID).Delete(&models.SpeciesAlias{}).Error; err != nil {
+			return err
+		}
+		if len(species.Aliases) > 0 {
+			if err := tx.Create(&species.Aliases).Error; err != nil {
+				return err
+			}
+		}
+		if previousName == species.Name {
+			return nil
+		}
+		return tx.Model(&models.Pet{}).Where("lower(species) = lower(?)", previousName).
+			Updates(map[string]interface{}{"species": species.Name, "version": gorm.Expr("version + 1")}).Error
+	})
+}
+
+func (r *taxonomyRepository) DeleteSpecies(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var species models.Species
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&species, id).Error; err != nil {
+			return err
+		}
+
+		var pets int64
+		if err := tx.Model(&models.Pet{}).Where("lower(species) = lower(?)", species.Name).Count(&pets).Error; err != nil {
+			return err
+		}
+		if pets > 0 {
+			return repositories.ErrSpeciesInUse
+		}
+
+		return tx.Delete(&species).Error
+	})
+}
+
+func (r *taxonomyRepository) GetBreedByID(id uint) (*models.Breed, error) {
+	var breed models.Breed
+
+	result := r.db.Preload("Aliases", orderByName).First(&breed, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &breed, nil
+}
+
+func (r *taxonomyRepository) FindBreed(speciesID uint, name string) (*models.Breed, error) {
+	var breed models.Breed
+
+	aliases := r.db.Model(&models.BreedAlias{}).Select("breed_id").
+		Where("species_id = ? AND lower(name) = lower(?)", speciesID, name)
+	result := r.db.Where("species_id = ?", speciesID).
+		Where(r.db.Where("lower(name) = lower(?)", name).Or("id IN (?)", aliases)).
+		First(&breed)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &breed, nil
+}
+
+func (r *taxonomyRepository) CreateBreed(breed *models.Breed) error {
+	result := r.db.Omit("Species").Create(breed)
+	return result.Error
+}
+
+// UpdateBreed replaces the breed's aliases and, when the name changed,
+// renames the breed on pets of its species too.
+func (r *taxonomyRepository) UpdateBreed(breed *models.Breed, previousName string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Aliases", "Species").Save(breed).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("breed_id = ?", breed.ID).Delete(&models.BreedAlias{}).Error; err != nil {
+			return err
+		}
+		if len(breed.Aliases) > 0 {
+			if err := tx.Create(&breed.Aliases).Error; err != nil {
+				return err
+			}
+		}
+		if previousName == breed.Name {
+			return nil
+		}
+		species := tx.Model(&models.Species{}).Select("name").Where("id = ?", breed.SpeciesID)
+		return tx.Model(&models.Pet{}).
+			Where("lower(species) = lower((?)) AND lower(breed) = lower(?)", species, previousName).
+			Updates(map[string]interface{}{"breed": breed.Name, "version": gorm.Expr("version + 1")}).Error
+	})
+}
+
+func (r *taxonomyRepository) DeleteBreed(id uint) error {
+	result := r.db.Delete(&models.Breed{}, id)
+	return result.Error
+}
+
+func orderByName(db *gorm.DB) *gorm.DB {
+	return db.Order("name")
+}
@@ -0,0 +1,230 @@
+package users
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"petstore-api/models"
+	"petstore-api/repositories"
+)
+
+// userRepository reads and writes the users table. When role is set, every
+// query is limited to users holding that role, so the seller and buyer
+// repositories see different slices of the same table.
+type userRepository struct {
+	db   *gorm.DB
+	role models.Role
+}
+
+func NewUserRepository(db *gorm.DB) repositories.UserRepository {
+	return &userRepository{db: db}
+}
+
+func NewSellerRepository(db *gorm.DB) repositories.UserRepository {
+	return &userRepository{db: db, role: models.RoleSeller}
+}
+
+func NewBuyerRepository(db *gorm.DB) repositories.UserRepository {
+	return &userRepository{db: db, role: models.RoleBuyer}
+}
+
+const hasRoleCondition = "EXISTS (SELECT 1 FROM user_roles WHERE user_roles.user_id = users.id AND user_roles.role = ?)"
+
+func (r *userRepository) query() *gorm.DB {
+	query := r.db.Preload("Roles")
+	if r.role != "" {
+		query = query.Where(hasRoleCondition, r.role)
+	}
+	return query
+}
+
+func (r *userRepository) GetAll(includePets bool, page models.PageRequest) ([]models.User, *models.PageInfo, error) {
+	users, info, err := repositories.FindPage(r.query(), page, []repositories.SortKey[models.User]{
+		{Column: "users.id", Value: func(u *models.User) any { return u.ID }},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if includePets {
+		if err := r.loadPets(users); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return users, info, nil
+}
+
+func (r *userRepository) GetByID(id uint, includePets bool) (*models.User, error) {
+	var user models.User
+
+	result := r.query().First(&user, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if includePets {
+		users := []models.User{user}
+		if err := r.loadPets(users); err != nil {
+			return nil, err
+		}
+		user = users[0]
+	}
+
+	return &user, nil
+}
+
+func (r *userRepository) GetByEmail(email string) (*models.User, error) {
+	var user models.User
+
+	result := r.query().Where("email = ?", email).First(&user)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &user, nil
+}
+
+func (r *userRepository) Create(user *models.User) error {
+	if r.role != "" && !user.HasRole(r.role) {
+		user.Roles = append(user.Roles, models.UserRole{Role: r.role})
+	}
+
+	result := r.db.Create(user)
+	return result.Error
+}
+
+// Update only writes the user if it is still at user.Version, and then
+// moves it to the next version; otherwise it fails with ErrVersionConflict.
+// It fails with ErrEmailTaken if another user already has the new email.
+func (r *userRepository) Update(user *models.User) error {
+	version := user.Version
+	user.Version++
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var taken int64
+		if err := tx.Model(&models.User{}).Where("email = ? AND id <> ?", user.Email, user.ID).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return repositories.ErrEmailTaken
+		}
+
+		result := tx.Model(user).Where("version = ?", version).Select("*").
+			Omit(clause.Associations, "ID", "CreatedAt", "DeletedAt").
+			Updates(user)
+		if result.Error == nil && result.RowsAffected == 0 {
+			return repositories.ErrVersionConflict
+		}
+		return result.Error
+	})
+	if err != nil {
+		user.Version = version
+	}
+	return err
+}
+
+// Delete removes the user from the repository's role. The user itself is
+// only soft-deleted once no other roles are left, so deleting a buyer who
+// also sells keeps the seller account intact. The last role is kept with
+// the deleted user so that restoring it brings the account back as it was.
+func (r *userRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if r.role != "" {
+			var others int64
+			if err := tx.Model(&models.UserRole{}).Where("user_id = ? AND role <> ?", id, r.role).Count(&others).Error; err != nil {
+				return err
+			}
+			if others > 0 {
+				return tx.Where("user_id = ? AND role = ?", id, r.role).Delete(&models.UserRole{}).Error
+			}
+		}
+
+		return tx.Delete(&models.User{}, id).Error
+	})
+}
+
+func (r *userRepository) AnyWithRole(role models.Role) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).Where(hasRoleCondition, role).Limit(1).Count(&count).Error
+	return count > 0, err
+}
+
+// Unscoped shares the connection but drops the soft-delete condition from
+// every query.
+func (r *userRepository) Unscoped() repositories.UserRepository {
+	return &userRepository{db: r.db.Unscoped().Session(&gorm.Session{}), role: r.role}
+}
+
+func (r *userRepository) Restore(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Unscoped().Where("deleted_at IS NOT NULL")
+		if r.role != "" {
+			query = query.Where(hasRoleCondition, r.role)
+		}
+		var user models.User
+		if err := query.First(&user, id).Error; err != nil {
+			return err
+		}
+
+		var taken int64
+		if err := tx.Model(&models.User{}).Where("email = ?", user.Email).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return repositories.ErrEmailTaken
+		}
+
+		return tx.Unscoped().Model(&user).Update("deleted_at", nil).Error
+	})
+}
+
+func (r *userRepository) Purge(cutoff time.Time) (int64, error) {
+	result := r.db.Unscoped().
+		Where("deleted_at < ?", cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM pets WHERE pets.seller_id = users.id)").
+		Where("NOT EXISTS (SELECT 1 FROM orders WHERE orders.buyer_id = users.id)").
+		Delete(&models.User{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *userRepository) GrantRole(userID uint, role models.Role) error {
+	grant := models.UserRole{UserID: userID, Role: role}
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&grant)
+	return result.Error
+}
+
+func (r *userRepository) RevokeRole(userID uint, role models.Role) error {
+	result := r.db.Where("user_id = ? AND role = ?", userID, role).Delete(&models.UserRole{})
+	return result.Error
+}
+
+// loadPets attaches each user's pets with a single query instead of one per user.
+func (r *userRepository) loadPets(users []models.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+
+	// Drafts and withdrawn pets are not part of a public seller profile.
+	var pets []models.Pet
+	hidden := []models.PetStatus{models.PetStatusDraft, models.PetStatusWithdrawn}
+	if err := r.db.Where("seller_id IN ? AND status NOT IN ?", ids, hidden).Find(&pets).Error; err != nil {
+		return err
+	}
+
+	bySeller := make(map[uint][]models.Pet)
+	for _, pet := range pets {
+		bySeller[pet.SellerID] = append(bySeller[pet.SellerID], pet)
+	}
+	for i := range users {
+		users[i].Pets = bySeller[users[i].ID]
+	}
+
+	return nil
+}
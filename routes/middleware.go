@@ -0,0 +1,67 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"petstore-api/auth"
+	"petstore-api/handlers"
+	"petstore-api/models"
+	"petstore-api/services"
+)
+
+// authenticate resolves the bearer token, if any, into an auth.Principal on
+// the request context. Requests without a token pass through anonymously so
+// public endpoints keep working; a malformed or expired token, or one whose
+// user has been deleted, is rejected.
+func authenticate(authService services.AuthService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if header == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok {
+				handlers.SendErrorResponse(w, http.StatusUnauthorized, "Authorization header must use the Bearer scheme")
+				return
+			}
+
+			principal, err := authService.Authenticate(token)
+			if err != nil {
+				if errors.Is(err, auth.ErrInvalidToken) || errors.Is(err, auth.ErrExpiredToken) {
+					handlers.SendErrorResponse(w, http.StatusUnauthorized, err.Error())
+					return
+				}
+				handlers.SendErrorResponse(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+func requireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := auth.PrincipalFromContext(r.Context()); !ok {
+			handlers.SendErrorResponse(w, http.StatusUnauthorized, "authentication required")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func requireRole(role models.Role, next http.HandlerFunc) http.HandlerFunc {
+	return requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ := auth.PrincipalFromContext(r.Context())
+		if !principal.HasRole(role) {
+			handlers.SendErrorResponse(w, http.StatusForbidden, "forbidden")
+			return
+		}
+		next(w, r)
+	})
+}
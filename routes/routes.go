@@ -4,6 +4,8 @@ import (
 	"net/http"
 
 	"petstore-api/handlers"
+	"petstore-api/models"
+	"petstore-api/services"
 
 	"github.com/gorilla/mux"
 	httpSwagger "github.com/swaggo/http-swagger"
@@ -13,7 +15,8 @@ func enableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -24,23 +27,101 @@ func enableCORS(next http.Handler) http.Handler {
 	})
 }
 
-func SetupRoutes(sellerHandler *handlers.SellerHandler, petHandler *handlers.PetHandler) http.Handler {
+func SetupRoutes(sellerHandler *handlers.SellerHandler, buyerHandler *handlers.BuyerHandler, bucketHandler *handlers.BucketHandler, favouriteHandler *handlers.FavouriteHandler, orderHandler *handlers.OrderHandler, paymentHandler *handlers.PaymentHandler, userHandler *handlers.UserHandler, authHandler *handlers.AuthHandler, petHandler *handlers.PetHandler, photoHandler *handlers.PhotoHandler, healthRecordHandler *handlers.HealthRecordHandler, pedigreeHandler *handlers.PedigreeHandler, taxonomyHandler *handlers.TaxonomyHandler, media http.Handler, authService services.AuthService) http.Handler {
 	r := mux.NewRouter()
 	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+	r.PathPrefix("/media/").Handler(http.StripPrefix("/media/", media)).Methods("GET")
 
 	api := r.PathPrefix("/").Subrouter()
+	api.Use(authenticate(authService))
+
+	api.HandleFunc("/auth/register", authHandler.Register).Methods("POST")
+	api.HandleFunc("/auth/login", authHandler.Login).Methods("POST")
+	api.HandleFunc("/auth/me", requireAuth(authHandler.Me)).Methods("GET")
 
 	api.HandleFunc("/sellers", sellerHandler.GetSellers).Methods("GET")
 	api.HandleFunc("/sellers/{id}", sellerHandler.GetSeller).Methods("GET")
-	api.HandleFunc("/sellers", sellerHandler.CreateSeller).Methods("POST")
-	api.HandleFunc("/sellers/{id}", sellerHandler.UpdateSeller).Methods("PUT")
-	api.HandleFunc("/sellers/{id}", sellerHandler.DeleteSeller).Methods("DELETE")
+	api.HandleFunc("/sellers", requireRole(models.RoleAdmin, sellerHandler.CreateSeller)).Methods("POST")
+	api.HandleFunc("/sellers/{id}", requireAuth(sellerHandler.UpdateSeller)).Methods("PUT")
+	api.HandleFunc("/sellers/{id}", requireAuth(sellerHandler.DeleteSeller)).Methods("DELETE")
+	api.HandleFunc("/sellers/{id}/restore", requireRole(models.RoleAdmin, sellerHandler.RestoreSeller)).Methods("POST")
+	api.HandleFunc("/sellers/{id}/orders", requireAuth(orderHandler.GetSellerOrders)).Methods("GET")
+	api.HandleFunc("/sellers/{id}/versions", requireAuth(sellerHandler.GetSellerVersions)).Methods("GET")
+	api.HandleFunc("/sellers/{id}/versions/{version}/restore", requireAuth(sellerHandler.RestoreSellerVersion)).Methods("POST")
+	api.HandleFunc("/sellers/{id}/pets/import", requireAuth(petHandler.ImportPets)).Methods("POST")
+	api.HandleFunc("/sellers/{id}/pets/export", petHandler.ExportSellerPets).Methods("GET")
+
+	api.HandleFunc("/buyers", requireRole(models.RoleAdmin, buyerHandler.GetBuyers)).Methods("GET")
+	api.HandleFunc("/buyers/{id}", requireAuth(buyerHandler.GetBuyer)).Methods("GET")
+	api.HandleFunc("/buyers", requireRole(models.RoleAdmin, buyerHandler.CreateBuyer)).Methods("POST")
+	api.HandleFunc("/buyers/{id}", requireAuth(buyerHandler.UpdateBuyer)).Methods("PUT")
+	api.HandleFunc("/buyers/{id}", requireAuth(buyerHandler.DeleteBuyer)).Methods("DELETE")
+	api.HandleFunc("/buyers/{id}/restore", requireRole(models.RoleAdmin, buyerHandler.RestoreBuyer)).Methods("POST")
+	api.HandleFunc("/buyers/{id}/versions", requireAuth(buyerHandler.GetBuyerVersions)).Methods("GET")
+	api.HandleFunc("/buyers/{id}/versions/{version}/restore", requireAuth(buyerHandler.RestoreBuyerVersion)).Methods("POST")
+
+	api.HandleFunc("/buyers/{id}/cart", requireAuth(bucketHandler.GetCart)).Methods("GET")
+	api.HandleFunc("/buyers/{id}/cart", requireAuth(bucketHandler.AddToCart)).Methods("POST")
+	api.HandleFunc("/buyers/{id}/cart/{petId}", requireAuth(bucketHandler.RemoveFromCart)).Methods("DELETE")
+
+	api.HandleFunc("/buyers/{id}/checkout", requireAuth(orderHandler.Checkout)).Methods("POST")
+	api.HandleFunc("/buyers/{id}/orders", requireAuth(orderHandler.GetBuyerOrders)).Methods("GET")
+
+	api.HandleFunc("/buyers/{id}/favourites", requireAuth(favouriteHandler.GetFavourites)).Methods("GET")
+	api.HandleFunc("/buyers/{id}/favourites/{petId}", requireAuth(favouriteHandler.AddFavourite)).Methods("PUT")
+	api.HandleFunc("/buyers/{id}/favourites/{petId}", requireAuth(favouriteHandler.RemoveFavourite)).Methods("DELETE")
+
+	api.HandleFunc("/orders/{id}", requireAuth(orderHandler.GetOrder)).Methods("GET")
+	api.HandleFunc("/orders/{id}/cancel", requireAuth(orderHandler.CancelOrder)).Methods("POST")
+	api.HandleFunc("/orders/{id}/status", requireAuth(orderHandler.UpdateOrderStatus)).Methods("PUT")
+	api.HandleFunc("/orders/{id}/pay", requireAuth(paymentHandler.PayOrder)).Methods("POST")
+	api.HandleFunc("/orders/{id}/refund", requireRole(models.RoleAdmin, paymentHandler.RefundOrder)).Methods("POST")
+	api.HandleFunc("/orders/{id}/payments", requireAuth(paymentHandler.GetOrderPayments)).Methods("GET")
+
+	api.HandleFunc("/webhooks/payments", paymentHandler.PaymentWebhook).Methods("POST")
+
+	api.HandleFunc("/users/{id}/roles/{role}", requireAuth(userHandler.GrantRole)).Methods("PUT")
+	api.HandleFunc("/users/{id}/roles/{role}", requireAuth(userHandler.RevokeRole)).Methods("DELETE")
 
 	api.HandleFunc("/pets", petHandler.GetPets).Methods("GET")
+	api.HandleFunc("/pets/search", petHandler.SearchPets).Methods("GET")
+	api.HandleFunc("/pets/facets", petHandler.GetPetFacets).Methods("GET")
+	api.HandleFunc("/pets/export", petHandler.ExportPets).Methods("GET")
 	api.HandleFunc("/pets/{id}", petHandler.GetPet).Methods("GET")
-	api.HandleFunc("/pets", petHandler.CreatePet).Methods("POST")
-	api.HandleFunc("/pets/{id}", petHandler.UpdatePet).Methods("PUT")
-	api.HandleFunc("/pets/{id}", petHandler.DeletePet).Methods("DELETE")
+	api.HandleFunc("/pets", requireAuth(petHandler.CreatePet)).Methods("POST")
+	api.HandleFunc("/pets/{id}", requireAuth(petHandler.UpdatePet)).Methods("PUT")
+	api.HandleFunc("/pets/{id}", requireAuth(petHandler.DeletePet)).Methods("DELETE")
+	api.HandleFunc("/pets/{id}/restore", requireAuth(petHandler.RestorePet)).Methods("POST")
+	api.HandleFunc("/pets/{id}/status", requireAuth(petHandler.UpdatePetStatus)).Methods("PUT")
+	api.HandleFunc("/pets/{id}/status-history", requireAuth(petHandler.GetPetStatusHistory)).Methods("GET")
+	api.HandleFunc("/pets/{id}/versions", requireAuth(petHandler.GetPetVersions)).Methods("GET")
+	api.HandleFunc("/pets/{id}/versions/{version}/restore", requireAuth(petHandler.RestorePetVersion)).Methods("POST")
+
+	api.HandleFunc("/pets/{id}/photos", photoHandler.GetPhotos).Methods("GET")
+	api.HandleFunc("/pets/{id}/photos", requireAuth(photoHandler.UploadPhoto)).Methods("POST")
+	api.HandleFunc("/pets/{id}/photos/order", requireAuth(photoHandler.ReorderPhotos)).Methods("PUT")
+	api.HandleFunc("/pets/{id}/photos/{photoId}/primary", requireAuth(photoHandler.SetPrimaryPhoto)).Methods("PUT")
+	api.HandleFunc("/pets/{id}/photos/{photoId}", requireAuth(photoHandler.DeletePhoto)).Methods("DELETE")
+
+	api.HandleFunc("/pets/{id}/health-records", healthRecordHandler.GetHealthRecords).Methods("GET")
+	api.HandleFunc("/pets/{id}/health-records", requireAuth(healthRecordHandler.AddHealthRecord)).Methods("POST")
+	api.HandleFunc("/pets/{id}/health-records/{recordId}", requireAuth(healthRecordHandler.UpdateHealthRecord)).Methods("PUT")
+	api.HandleFunc("/pets/{id}/health-records/{recordId}", requireAuth(healthRecordHandler.DeleteHealthRecord)).Methods("DELETE")
+
+	api.HandleFunc("/pets/{id}/pedigree", pedigreeHandler.GetPedigree).Methods("GET")
+	api.HandleFunc("/pets/{id}/parents", requireAuth(pedigreeHandler.SetParents)).Methods("PUT")
+	api.HandleFunc("/pedigree-entries", requireAuth(pedigreeHandler.CreatePedigreeEntry)).Methods("POST")
+	api.HandleFunc("/pedigree-entries/{id}", pedigreeHandler.GetPedigreeEntry).Methods("GET")
+
+	api.HandleFunc("/species", taxonomyHandler.GetAllSpecies).Methods("GET")
+	api.HandleFunc("/species/{id}", taxonomyHandler.GetSpecies).Methods("GET")
+	api.HandleFunc("/species", requireRole(models.RoleAdmin, taxonomyHandler.CreateSpecies)).Methods("POST")
+	api.HandleFunc("/species/{id}", requireRole(models.RoleAdmin, taxonomyHandler.UpdateSpecies)).Methods("PUT")
+	api.HandleFunc("/species/{id}", requireRole(models.RoleAdmin, taxonomyHandler.DeleteSpecies)).Methods("DELETE")
+	api.HandleFunc("/species/{id}/breeds", taxonomyHandler.GetBreeds).Methods("GET")
+	api.HandleFunc("/species/{id}/breeds", requireRole(models.RoleAdmin, taxonomyHandler.CreateBreed)).Methods("POST")
+	api.HandleFunc("/breeds/{id}", requireRole(models.RoleAdmin, taxonomyHandler.UpdateBreed)).Methods("PUT")
+	api.HandleFunc("/breeds/{id}", requireRole(models.RoleAdmin, taxonomyHandler.DeleteBreed)).Methods("DELETE")
 
 	return enableCORS(r)
 }
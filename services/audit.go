@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"petstore-api/auth"
+	"petstore-api/models"
+	"petstore-api/repositories"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"gorm.io/gorm"
+)
+
+// recordAudit stores the next version of an entity. entry names the entity
+// and the action; before and after are its snapshots around the change,
+// nil for one that did not exist. Updates that change nothing are not
+// recorded. The change itself is already committed in Postgres by then, so
+// a failure to reach MongoDB is logged instead of failing the request.
+func recordAudit(ctx context.Context, auditRepo repositories.AuditRepository, entry models.AuditEntry, before, after any) {
+	entry.Changes = models.AuditChanges(before, after)
+	if len(entry.Changes) == 0 && (entry.Action == models.AuditActionUpdate || entry.Action == models.AuditActionRestore) {
+		return
+	}
+
+	entry.ActorID = changedBy(ctx)
+	entry.Timestamp = time.Now()
+	if entry.Action == models.AuditActionDelete {
+		entry.Snapshot = models.AuditSnapshot(before)
+	} else {
+		entry.Snapshot = models.AuditSnapshot(after)
+	}
+
+	if err := auditRepo.Record(&entry); err != nil {
+		log.Printf("failed to record %s of %s %d: %v", entry.Action, entry.EntityType, entry.EntityID, err)
+	}
+}
+
+// getAuditVersion loads one version of an entity and decodes its snapshot
+// into snapshot.
+func getAuditVersion(auditRepo repositories.AuditRepository, entityType models.AuditEntityType, entityID uint, version int, snapshot any) (*models.AuditEntry, error) {
+	entry, err := auditRepo.GetVersion(entityType, entityID, version)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.New("version not found")
+		}
+		return nil, err
+	}
+
+	if err := decodeSnapshot(entry.Snapshot, snapshot); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// decodeSnapshot maps a stored snapshot onto its typed struct by field
+// name. Snapshots come back from MongoDB with its own number types, so
+// they cannot be used as they are.
+func decodeSnapshot(stored map[string]any, snapshot any) error {
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, snapshot)
+}
+
+// userAudit records changes made through the seller and buyer services and
+// gives both the same version history endpoints, since sellers and buyers
+// are rows of the same users table.
+type userAudit struct {
+	userRepo  repositories.UserRepository
+	auditRepo repositories.AuditRepository
+	role      models.Role
+	notFound  string
+}
+
+func (u userAudit) GetVersions(ctx context.Context, id uint) ([]models.AuditEntry, error) {
+	if err := authorizeUser(ctx, id); err != nil {
+		return nil, err
+	}
+	return u.auditRepo.GetHistory(models.AuditEntityUser, id)
+}
+
+// RestoreVersion puts back the profile fields a user had in an earlier
+// version. Roles are granted and revoked through their own endpoints and
+// are not restored. Like Update it fails with ErrVersionConflict when the
+// user is not at a version ifMatch allows.
+func (u userAudit) RestoreVersion(ctx context.Context, id uint, version int, ifMatch models.VersionMatch) (*models.User, error) {
+	if err := authorizeUser(ctx, id); err != nil {
+		return nil, err
+	}
+
+	user, err := u.userRepo.GetByID(id, false)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New(u.notFound)
+		}
+		return nil, err
+	}
+	if !ifMatch.Matches(user.Version) {
+		return nil, repositories.ErrVersionConflict
+	}
+
+	var snapshot models.UserSnapshot
+	entry, err := getAuditVersion(u.auditRepo, models.AuditEntityUser, id, version, &snapshot)
+	if err != nil {
+		return nil, err
+	}
+	before := models.NewUserSnapshot(user)
+
+	user.Name = snapshot.Name
+	user.Email = snapshot.Email
+	user.Phone = snapshot.Phone
+	user.Address = snapshot.Address
+
+	if err := u.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	recordAudit(ctx, u.auditRepo, models.AuditEntry{
+		EntityType:   models.AuditEntityUser,
+		EntityID:     id,
+		Action:       models.AuditActionRestore,
+		RestoredFrom: &entry.Version,
+	}, before, models.NewUserSnapshot(user))
+
+	return user, nil
+}
+
+// Restore brings back a deleted user that has not been purged yet, with
+// the roles it had when it was deleted. Only admins may restore users.
+func (u userAudit) Restore(ctx context.Context, id uint) (*models.User, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok || !principal.IsAdmin() {
+		return nil, ErrForbidden
+	}
+
+	user, err := u.userRepo.Unscoped().GetByID(id, false)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New(u.notFound)
+		}
+		return nil, err
+	}
+	if !user.DeletedAt.Valid {
+		return nil, errors.New("user is not deleted")
+	}
+
+	if err := u.userRepo.Restore(id); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("user is not deleted")
+		}
+		return nil, err
+	}
+	user.DeletedAt = gorm.DeletedAt{}
+	u.record(ctx, id, models.AuditActionUndelete, nil, models.NewUserSnapshot(user))
+
+	return user, nil
+}
+
+// users returns the repository to read users through, which also sees
+// deleted users when an admin asks for them.
+func (u userAudit) users(ctx context.Context, includeDeleted bool) (repositories.UserRepository, error) {
+	if err := authorizeIncludeDeleted(ctx, includeDeleted); err != nil {
+		return nil, err
+	}
+	if includeDeleted {
+		return u.userRepo.Unscoped(), nil
+	}
+	return u.userRepo, nil
+}
+
+func (u userAudit) recordCreate(ctx context.Context, user *models.User) {
+	u.record(ctx, user.ID, models.AuditActionCreate, nil, models.NewUserSnapshot(user))
+}
+
+func (u userAudit) recordUpdate(ctx context.Context, before *models.UserSnapshot, user *models.User) {
+	u.record(ctx, user.ID, models.AuditActionUpdate, before, models.NewUserSnapshot(user))
+}
+
+// recordDelete records the removal of the service's role from user. The
+// user row only goes away with its last role; otherwise this is an update
+// of the roles.
+func (u userAudit) recordDelete(ctx context.Context, user *models.User) {
+	before := models.NewUserSnapshot(user)
+	after := *before
+	after.Roles = nil
+	for _, role := range before.Roles {
+		if role != string(u.role) {
+			after.Roles = append(after.Roles, role)
+		}
+	}
+
+	if len(after.Roles) == 0 {
+		u.record(ctx, user.ID, models.AuditActionDelete, before, nil)
+		return
+	}
+	u.record(ctx, user.ID, models.AuditActionUpdate, before, &after)
+}
+
+func (u userAudit) record(ctx context.Context, id uint, action models.AuditAction, before, after *models.UserSnapshot) {
+	recordAudit(ctx, u.auditRepo, models.AuditEntry{EntityType: models.AuditEntityUser, EntityID: id, Action: action}, before, after)
+}
+
+// petStatusAudit records the pets an order moves between statuses. Orders
+// move them inside their own transaction, so the pets are read before and
+// after it and every pet that changed gets an update entry.
+type petStatusAudit struct {
+	petRepo   repositories.PetRepository
+	auditRepo repositories.AuditRepository
+}
+
+// snapshot reads the current state of the pets. Like recordAudit it only
+// logs a failure, since it must not fail an order that goes through.
+func (a petStatusAudit) snapshot(petIDs []uint) map[uint]*models.PetSnapshot {
+	if len(petIDs) == 0 {
+		return nil
+	}
+	pets, err := a.petRepo.GetByIDs(petIDs)
+	if err != nil {
+		log.Printf("failed to read pets %v for the audit trail: %v", petIDs, err)
+		return nil
+	}
+
+	snapshots := make(map[uint]*models.PetSnapshot, len(pets))
+	for i := range pets {
+		snapshots[pets[i].ID] = models.NewPetSnapshot(&pets[i])
+	}
+	return snapshots
+}
+
+// record compares the pets with before, taken by snapshot, and records the
+// ones that changed.
+func (a petStatusAudit) record(ctx context.Context, before map[uint]*models.PetSnapshot) {
+	petIDs := make([]uint, 0, len(before))
+	for id := range before {
+		petIDs = append(petIDs, id)
+	}
+	slices.Sort(petIDs)
+	after := a.snapshot(petIDs)
+
+	for _, id := range petIDs {
+		if after[id] == nil {
+			continue
+		}
+		recordAudit(ctx, a.auditRepo, models.AuditEntry{EntityType: models.AuditEntityPet, EntityID: id, Action: models.AuditActionUpdate}, before[id], after[id])
+	}
+}
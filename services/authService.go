@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"petstore-api/auth"
+	"petstore-api/models"
+	"petstore-api/repositories"
+
+	"gorm.io/gorm"
+)
+
+type authService struct {
+	userRepo   repositories.UserRepository
+	auditRepo  repositories.AuditRepository
+	tokens     *auth.TokenManager
+	adminEmail string
+}
+
+// NewAuthService creates the registration and login service. A user who
+// registers with adminEmail while there is no admin yet, not even a deleted
+// one, is granted the admin role, which is how the first administrator of a
+// fresh deployment is created. Later admins are granted the role by an admin.
+func NewAuthService(userRepo repositories.UserRepository, auditRepo repositories.AuditRepository, tokens *auth.TokenManager, adminEmail string) AuthService {
+	return &authService{
+		userRepo:   userRepo,
+		auditRepo:  auditRepo,
+		tokens:     tokens,
+		adminEmail: adminEmail,
+	}
+}
+
+func (s *authService) Register(ctx context.Context, req *models.RegisterRequest) (*models.User, error) {
+	if req.Name == "" || req.Email == "" || req.Password == "" {
+		return nil, errors.New("name, email and password are required")
+	}
+	if len(req.Password) < auth.MinPasswordLength {
+		return nil, errors.New("password must be at least 8 characters")
+	}
+
+	roles := req.Roles
+	if len(roles) == 0 {
+		roles = []models.Role{models.RoleBuyer}
+	}
+
+	_, err := s.userRepo.GetByEmail(req.Email)
+	if err == nil {
+		return nil, errors.New("email already registered")
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Name:         req.Name,
+		Email:        req.Email,
+		Phone:        req.Phone,
+		Address:      req.Address,
+		PasswordHash: hash,
+	}
+	for _, role := range roles {
+		if role != models.RoleSeller && role != models.RoleBuyer {
+			return nil, errors.New("roles may only contain seller and buyer")
+		}
+		if !user.HasRole(role) {
+			user.Roles = append(user.Roles, models.UserRole{Role: role})
+		}
+	}
+	if s.adminEmail != "" && strings.EqualFold(req.Email, s.adminEmail) {
+		// The address is not verified, so it only bootstraps the first
+		// admin. Deleted admins count, or deleting the admin would hand the
+		// role to whoever registers the address next.
+		adminExists, err := s.userRepo.Unscoped().AnyWithRole(models.RoleAdmin)
+		if err != nil {
+			return nil, err
+		}
+		if !adminExists {
+			user.Roles = append(user.Roles, models.UserRole{Role: models.RoleAdmin})
+		}
+	}
+
+	err = s.userRepo.Create(user)
+	if err != nil {
+		return nil, err
+	}
+	recordAudit(ctx, s.auditRepo, models.AuditEntry{EntityType: models.AuditEntityUser, EntityID: user.ID, Action: models.AuditActionCreate}, nil, models.NewUserSnapshot(user))
+
+	return user, nil
+}
+
+func (s *authService) Login(req *models.LoginRequest) (*models.LoginResponse, error) {
+	user, err := s.userRepo.GetByEmail(req.Email)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("invalid email or password")
+		}
+		return nil, err
+	}
+
+	if user.PasswordHash == "" || !auth.CheckPassword(user.PasswordHash, req.Password) {
+		return nil, errors.New("invalid email or password")
+	}
+
+	token, expiresAt, err := s.tokens.Issue(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.LoginResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresAt:   expiresAt,
+		User:        user,
+	}, nil
+}
+
+func (s *authService) CurrentUser(ctx context.Context) (*models.User, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, ErrForbidden
+	}
+
+	user, err := s.userRepo.GetByID(principal.UserID, false)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *authService) Authenticate(token string) (*auth.Principal, error) {
+	principal, err := s.tokens.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(principal.UserID, false)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, auth.ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	roles := make([]models.Role, 0, len(user.Roles))
+	for _, r := range user.Roles {
+		roles = append(roles, r.Role)
+	}
+	return &auth.Principal{UserID: user.ID, Roles: roles}, nil
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"petstore-api/models"
+	"petstore-api/repositories"
+
+	"gorm.io/gorm"
+)
+
+type bucketService struct {
+	bucketRepo repositories.UserItemRepository
+	buyerRepo  repositories.UserRepository
+	petRepo    repositories.PetRepository
+}
+
+func NewBucketService(bucketRepo repositories.UserItemRepository, buyerRepo repositories.UserRepository, petRepo repositories.PetRepository) BucketService {
+	return &bucketService{
+		bucketRepo: bucketRepo,
+		buyerRepo:  buyerRepo,
+		petRepo:    petRepo,
+	}
+}
+
+func (s *bucketService) GetCart(ctx context.Context, buyerID uint) (*models.Cart, error) {
+	if err := s.checkBuyer(ctx, buyerID); err != nil {
+		return nil, err
+	}
+	return s.loadCart(buyerID)
+}
+
+func (s *bucketService) AddToCart(ctx context.Context, buyerID uint, petID uint) (*models.Cart, error) {
+	if err := s.checkBuyer(ctx, buyerID); err != nil {
+		return nil, err
+	}
+
+	pet, err := s.petRepo.GetByID(petID, false)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("pet not found")
+		}
+		return nil, err
+	}
+	if pet.Status != models.PetStatusListed {
+		return nil, errors.New("pet is not available")
+	}
+
+	if err := s.bucketRepo.AddPet(buyerID, petID); err != nil {
+		return nil, err
+	}
+
+	return s.loadCart(buyerID)
+}
+
+func (s *bucketService) RemoveFromCart(ctx context.Context, buyerID uint, petID uint) (*models.Cart, error) {
+	if err := s.checkBuyer(ctx, buyerID); err != nil {
+		return nil, err
+	}
+
+	if err := s.bucketRepo.RemovePet(buyerID, petID); err != nil {
+		return nil, err
+	}
+
+	return s.loadCart(buyerID)
+}
+
+func (s *bucketService) checkBuyer(ctx context.Context, buyerID uint) error {
+	if err := authorizeUser(ctx, buyerID); err != nil {
+		return err
+	}
+
+	_, err := s.buyerRepo.GetByID(buyerID, false)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("buyer not found")
+		}
+		return err
+	}
+	return nil
+}
+
+// loadCart hydrates the pet IDs kept in MongoDB from Postgres. Pets that have
+// been deleted or are no longer listed are left out of the response but
+// stay in the stored cart, so they come back if they are listed again.
+func (s *bucketService) loadCart(buyerID uint) (*models.Cart, error) {
+	petIDs, err := s.bucketRepo.GetPetIDs(buyerID)
+	if err != nil {
+		return nil, err
+	}
+
+	pets, err := s.petRepo.GetByIDs(petIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]models.Pet, len(pets))
+	for _, pet := range pets {
+		byID[pet.ID] = pet
+	}
+
+	cart := &models.Cart{BuyerID: buyerID, Pets: []models.Pet{}}
+	for _, id := range petIDs {
+		if pet, ok := byID[id]; ok && pet.Status == models.PetStatusListed {
+			cart.Pets = append(cart.Pets, pet)
+		}
+	}
+
+	prices := make([]models.Money, len(cart.Pets))
+	for i, pet := range cart.Pets {
+		prices[i] = pet.Price
+	}
+	cart.Totals = models.SumByCurrency(prices)
+
+	return cart, nil
+}
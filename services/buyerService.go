@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 
 	"petstore-api/models"
@@ -10,25 +11,40 @@ import (
 )
 
 type buyerService struct {
+	userAudit
 	buyerRepo repositories.UserRepository
 }
 
-func (b *buyerService) GetAll(includePets bool) ([]models.User, error) {
-	return b.buyerRepo.GetAll(false)
+func (b *buyerService) GetAll(ctx context.Context, includePets bool, includeDeleted bool, page models.PageRequest) ([]models.User, *models.PageInfo, error) {
+	buyerRepo, err := b.users(ctx, includeDeleted)
+	if err != nil {
+		return nil, nil, err
+	}
+	return buyerRepo.GetAll(false, page)
 }
 
-func (b *buyerService) GetByID(id uint, includePets bool) (*models.User, error) {
-	buyer, err := b.buyerRepo.GetByID(id, false)
+// GetByID returns a buyer to the buyer themselves or an admin; buyers'
+// contact details are not public like sellers' are.
+func (b *buyerService) GetByID(ctx context.Context, id uint, includePets bool, includeDeleted bool) (*models.User, error) {
+	if err := authorizeUser(ctx, id); err != nil {
+		return nil, err
+	}
+	buyerRepo, err := b.users(ctx, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+
+	buyer, err := buyerRepo.GetByID(id, false)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, errors.New("seller not found")
+			return nil, errors.New("buyer not found")
 		}
 		return nil, err
 	}
 	return buyer, nil
 }
 
-func (b *buyerService) Create(req *models.CreateUserRequest) (*models.User, error) {
+func (b *buyerService) Create(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
 	if req.Name == "" || req.Email == "" {
 		return nil, errors.New("name and email are required")
 	}
@@ -44,11 +60,16 @@ func (b *buyerService) Create(req *models.CreateUserRequest) (*models.User, erro
 	if err != nil {
 		return nil, err
 	}
+	b.recordCreate(ctx, buyer)
 
 	return buyer, nil
 }
 
-func (b *buyerService) Update(id uint, req *models.UpdateUserRequest) (*models.User, error) {
+func (b *buyerService) Update(ctx context.Context, id uint, req *models.UpdateUserRequest, ifMatch models.VersionMatch) (*models.User, error) {
+	if err := authorizeUser(ctx, id); err != nil {
+		return nil, err
+	}
+
 	buyer, err := b.buyerRepo.GetByID(id, false)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -56,6 +77,10 @@ func (b *buyerService) Update(id uint, req *models.UpdateUserRequest) (*models.U
 		}
 		return nil, err
 	}
+	if !ifMatch.Matches(buyer.Version) {
+		return nil, repositories.ErrVersionConflict
+	}
+	before := models.NewUserSnapshot(buyer)
 
 	if req.Name != "" {
 		buyer.Name = req.Name
@@ -74,23 +99,39 @@ func (b *buyerService) Update(id uint, req *models.UpdateUserRequest) (*models.U
 	if err != nil {
 		return nil, err
 	}
+	b.recordUpdate(ctx, before, buyer)
 
 	return buyer, nil
 }
 
-func (b *buyerService) Delete(id uint) error {
-	_, err := b.buyerRepo.GetByID(id, false)
+func (b *buyerService) Delete(ctx context.Context, id uint) error {
+	if err := authorizeUser(ctx, id); err != nil {
+		return err
+	}
+
+	buyer, err := b.buyerRepo.GetByID(id, false)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return errors.New("seller not found")
+			return errors.New("buyer not found")
 		}
 		return err
 	}
-	return b.buyerRepo.Delete(id)
+
+	if err := b.buyerRepo.Delete(id); err != nil {
+		return err
+	}
+	b.recordDelete(ctx, buyer)
+	return nil
 }
 
-func NewBuyerService(buyerRepo repositories.UserRepository) UserService {
+func NewBuyerService(buyerRepo repositories.UserRepository, auditRepo repositories.AuditRepository) UserService {
 	return &buyerService{
+		userAudit: userAudit{
+			userRepo:  buyerRepo,
+			auditRepo: auditRepo,
+			role:      models.RoleBuyer,
+			notFound:  "buyer not found",
+		},
 		buyerRepo: buyerRepo,
 	}
 }
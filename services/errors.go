@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"petstore-api/auth"
+)
+
+// ErrForbidden is returned when the caller is known but is not allowed to
+// act on the requested resource.
+var ErrForbidden = errors.New("forbidden")
+
+var (
+	// ErrSellerNotFound is returned when a pet is assigned to a user that
+	// does not exist.
+	ErrSellerNotFound = errors.New("seller not found")
+
+	// ErrNotSeller is returned when a pet is assigned to a user without the
+	// seller role.
+	ErrNotSeller = errors.New("user does not have the seller role")
+)
+
+// authorizeUser allows the user themselves or an admin to act on userID. It
+// also covers resources owned by a user, such as a seller's pets.
+func authorizeUser(ctx context.Context, userID uint) error {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return ErrForbidden
+	}
+	if principal.UserID != userID && !principal.IsAdmin() {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// authorizeIncludeDeleted lets only admins see deleted pets and users.
+func authorizeIncludeDeleted(ctx context.Context, includeDeleted bool) error {
+	if !includeDeleted {
+		return nil
+	}
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok || !principal.IsAdmin() {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// changedBy returns the caller's user ID for history records, or nil when
+// the change has no authenticated caller.
+func changedBy(ctx context.Context) *uint {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return &principal.UserID
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"petstore-api/models"
+	"petstore-api/repositories"
+
+	"gorm.io/gorm"
+)
+
+type favouriteService struct {
+	favouriteRepo repositories.FavouriteRepository
+	buyerRepo     repositories.UserRepository
+	petRepo       repositories.PetRepository
+}
+
+func NewFavouriteService(favouriteRepo repositories.FavouriteRepository, buyerRepo repositories.UserRepository, petRepo repositories.PetRepository) FavouriteService {
+	return &favouriteService{
+		favouriteRepo: favouriteRepo,
+		buyerRepo:     buyerRepo,
+		petRepo:       petRepo,
+	}
+}
+
+func (s *favouriteService) GetFavourites(ctx context.Context, buyerID uint) (*models.Favourites, error) {
+	if err := s.checkBuyer(ctx, buyerID); err != nil {
+		return nil, err
+	}
+	return s.loadFavourites(buyerID)
+}
+
+func (s *favouriteService) AddFavourite(ctx context.Context, buyerID uint, petID uint) (*models.Favourites, error) {
+	if err := s.checkBuyer(ctx, buyerID); err != nil {
+		return nil, err
+	}
+
+	// Deleted pets are not found at all, and drafts and withdrawn pets are
+	// hidden as they are from GET /pets/{id}.
+	pet, err := s.petRepo.GetByID(petID, false)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("pet not found")
+		}
+		return nil, err
+	}
+	if !pet.Status.Public() && authorizeUser(ctx, pet.SellerID) != nil {
+		return nil, errors.New("pet not found")
+	}
+
+	if err := s.favouriteRepo.AddPet(buyerID, petID); err != nil {
+		return nil, err
+	}
+
+	return s.loadFavourites(buyerID)
+}
+
+func (s *favouriteService) RemoveFavourite(ctx context.Context, buyerID uint, petID uint) (*models.Favourites, error) {
+	if err := s.checkBuyer(ctx, buyerID); err != nil {
+		return nil, err
+	}
+
+	if err := s.favouriteRepo.RemovePet(buyerID, petID); err != nil {
+		return nil, err
+	}
+
+	return s.loadFavourites(buyerID)
+}
+
+func (s *favouriteService) checkBuyer(ctx context.Context, buyerID uint) error {
+	if err := authorizeUser(ctx, buyerID); err != nil {
+		return err
+	}
+
+	_, err := s.buyerRepo.GetByID(buyerID, false)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("buyer not found")
+		}
+		return err
+	}
+	return nil
+}
+
+// loadFavourites hydrates the stored pet IDs from Postgres. Unlike the cart,
+// reserved and sold pets stay visible; deleted pets, drafts and withdrawn
+// pets are left out.
+func (s *favouriteService) loadFavourites(buyerID uint) (*models.Favourites, error) {
+	petIDs, err := s.favouriteRepo.GetPetIDs(buyerID)
+	if err != nil {
+		return nil, err
+	}
+
+	pets, err := s.petRepo.GetByIDs(petIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]models.Pet, len(pets))
+	for _, pet := range pets {
+		byID[pet.ID] = pet
+	}
+
+	favourites := &models.Favourites{BuyerID: buyerID, Pets: []models.Pet{}}
+	for _, id := range petIDs {
+		if pet, ok := byID[id]; ok && pet.Status.Public() {
+			favourites.Pets = append(favourites.Pets, pet)
+		}
+	}
+
+	return favourites, nil
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"petstore-api/models"
+	"petstore-api/repositories"
+
+	"gorm.io/gorm"
+)
+
+type healthRecordService struct {
+	recordRepo repositories.HealthRecordRepository
+	petRepo    repositories.PetRepository
+}
+
+func NewHealthRecordService(recordRepo repositories.HealthRecordRepository, petRepo repositories.PetRepository) HealthRecordService {
+	return &healthRecordService{
+		recordRepo: recordRepo,
+		petRepo:    petRepo,
+	}
+}
+
+// GetRecords hides the records of drafts and withdrawn pets from everyone
+// but their seller and admins.
+func (s *healthRecordService) GetRecords(ctx context.Context, petID uint) ([]models.HealthRecord, error) {
+	pet, err := s.getPet(petID)
+	if err != nil {
+		return nil, err
+	}
+	if !pet.Status.Public() && authorizeUser(ctx, pet.SellerID) != nil {
+		return nil, errors.New("pet not found")
+	}
+	return s.recordRepo.GetByPetID(petID)
+}
+
+func (s *healthRecordService) AddRecord(ctx context.Context, petID uint, req *models.HealthRecordRequest) (*models.HealthRecord, error) {
+	if _, err := s.getOwnedPet(ctx, petID); err != nil {
+		return nil, err
+	}
+	if err := req.Validate(models.NewDate(time.Now())); err != nil {
+		return nil, err
+	}
+
+	record := &models.HealthRecord{PetID: petID}
+	applyHealthRecord(record, req)
+	if err := s.recordRepo.Create(record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+func (s *healthRecordService) UpdateRecord(ctx context.Context, petID uint, recordID uint, req *models.HealthRecordRequest) (*models.HealthRecord, error) {
+	record, err := s.getOwnedRecord(ctx, petID, recordID)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Validate(models.NewDate(time.Now())); err != nil {
+		return nil, err
+	}
+
+	applyHealthRecord(record, req)
+	if err := s.recordRepo.Update(record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+func (s *healthRecordService) DeleteRecord(ctx context.Context, petID uint, recordID uint) error {
+	if _, err := s.getOwnedRecord(ctx, petID, recordID); err != nil {
+		return err
+	}
+
+	return s.recordRepo.Delete(recordID)
+}
+
+func applyHealthRecord(record *models.HealthRecord, req *models.HealthRecordRequest) {
+	record.Type = req.Type
+	record.Name = req.Name
+	record.Date = *req.Date
+	record.Vet = req.Vet
+	record.NextDueDate = req.NextDueDate
+	record.Notes = req.Notes
+}
+
+func (s *healthRecordService) getPet(id uint) (*models.Pet, error) {
+	pet, err := s.petRepo.GetByID(id, false)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("pet not found")
+		}
+		return nil, err
+	}
+	return pet, nil
+}
+
+// getOwnedPet loads a pet whose records the caller may change: its seller
+// or an admin.
+func (s *healthRecordService) getOwnedPet(ctx context.Context, id uint) (*models.Pet, error) {
+	pet, err := s.getPet(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeUser(ctx, pet.SellerID); err != nil {
+		return nil, err
+	}
+	return pet, nil
+}
+
+func (s *healthRecordService) getOwnedRecord(ctx context.Context, petID uint, recordID uint) (*models.HealthRecord, error) {
+	if _, err := s.getOwnedPet(ctx, petID); err != nil {
+		return nil, err
+	}
+
+	record, err := s.recordRepo.GetByID(recordID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("health record not found")
+		}
+		return nil, err
+	}
+	if record.PetID != petID {
+		return nil, errors.New("health record not found")
+	}
+	return record, nil
+}
@@ -1,19 +1,124 @@
 package services
 
-import "petstore-api/models"
+import (
+	"context"
+	"time"
+
+	"petstore-api/auth"
+	"petstore-api/models"
+)
 
 type UserService interface {
-	GetAll(includePets bool) ([]models.User, error)
-	GetByID(id uint, includePets bool) (*models.User, error)
-	Create(req *models.CreateUserRequest) (*models.User, error)
-	Update(id uint, req *models.UpdateUserRequest) (*models.User, error)
-	Delete(id uint) error
+	GetAll(ctx context.Context, includePets bool, includeDeleted bool, page models.PageRequest) ([]models.User, *models.PageInfo, error)
+	GetByID(ctx context.Context, id uint, includePets bool, includeDeleted bool) (*models.User, error)
+	Create(ctx context.Context, req *models.CreateUserRequest) (*models.User, error)
+	Update(ctx context.Context, id uint, req *models.UpdateUserRequest, ifMatch models.VersionMatch) (*models.User, error)
+	Delete(ctx context.Context, id uint) error
+	Restore(ctx context.Context, id uint) (*models.User, error)
+	GetVersions(ctx context.Context, id uint) ([]models.AuditEntry, error)
+	RestoreVersion(ctx context.Context, id uint, version int, ifMatch models.VersionMatch) (*models.User, error)
 }
 
 type PetService interface {
-	GetAllPets(includeSeller bool, sellerID *uint) ([]models.Pet, error)
-	GetPetByID(id uint, includeSeller bool) (*models.Pet, error)
-	CreatePet(req *models.CreatePetRequest) (*models.Pet, error)
-	UpdatePet(id uint, req *models.UpdatePetRequest) (*models.Pet, error)
-	DeletePet(id uint) error
+	GetAllPets(ctx context.Context, includeSeller bool, filter models.PetFilter, page models.PageRequest) ([]models.Pet, *models.PageInfo, error)
+	SearchPets(ctx context.Context, text string, filter models.PetFilter, page models.PageRequest) ([]models.PetSearchResult, *models.PageInfo, error)
+	GetPetFacets(ctx context.Context, filter models.PetFilter, options models.PetFacetOptions) (*models.PetFacets, error)
+	// ExportPets calls fn for every pet matching filter, one at a time,
+	// with the same visibility rules as GetAllPets.
+	ExportPets(ctx context.Context, filter models.PetFilter, fn func(pet *models.Pet) error) error
+	GetPetByID(ctx context.Context, id uint, includeSeller bool, includeDeleted bool) (*models.Pet, error)
+	CreatePet(ctx context.Context, req *models.CreatePetRequest) (*models.Pet, error)
+	ImportPets(ctx context.Context, sellerID uint, rows []models.PetImportRow, options models.PetImportOptions) (*models.PetImportReport, error)
+	UpdatePet(ctx context.Context, id uint, req *models.UpdatePetRequest, ifMatch models.VersionMatch) (*models.Pet, error)
+	ChangeStatus(ctx context.Context, id uint, req *models.UpdatePetStatusRequest, ifMatch models.VersionMatch) (*models.Pet, error)
+	GetStatusHistory(ctx context.Context, id uint) ([]models.PetStatusHistory, error)
+	DeletePet(ctx context.Context, id uint) error
+	RestorePet(ctx context.Context, id uint) (*models.Pet, error)
+	GetVersions(ctx context.Context, id uint) ([]models.AuditEntry, error)
+	RestoreVersion(ctx context.Context, id uint, version int, ifMatch models.VersionMatch) (*models.Pet, error)
+}
+
+type PhotoService interface {
+	AddPhoto(ctx context.Context, petID uint, data []byte) (*models.PetPhoto, error)
+	GetPhotos(ctx context.Context, petID uint) ([]models.PetPhoto, error)
+	DeletePhoto(ctx context.Context, petID uint, photoID uint) error
+	ReorderPhotos(ctx context.Context, petID uint, photoIDs []uint) ([]models.PetPhoto, error)
+	SetPrimaryPhoto(ctx context.Context, petID uint, photoID uint) ([]models.PetPhoto, error)
+}
+
+type HealthRecordService interface {
+	GetRecords(ctx context.Context, petID uint) ([]models.HealthRecord, error)
+	AddRecord(ctx context.Context, petID uint, req *models.HealthRecordRequest) (*models.HealthRecord, error)
+	UpdateRecord(ctx context.Context, petID uint, recordID uint, req *models.HealthRecordRequest) (*models.HealthRecord, error)
+	DeleteRecord(ctx context.Context, petID uint, recordID uint) error
+}
+
+type PedigreeService interface {
+	GetPedigree(ctx context.Context, petID uint, generations int) (*models.PedigreeNode, error)
+	SetParents(ctx context.Context, petID uint, req *models.SetParentsRequest, ifMatch models.VersionMatch) (*models.Pet, error)
+	CreateEntry(req *models.CreatePedigreeEntryRequest) (*models.PedigreeEntry, error)
+	GetEntry(id uint) (*models.PedigreeEntry, error)
+}
+
+type TaxonomyService interface {
+	GetAllSpecies() ([]models.Species, error)
+	GetSpecies(id uint) (*models.Species, error)
+	CreateSpecies(req *models.TaxonomyRequest) (*models.Species, error)
+	UpdateSpecies(id uint, req *models.TaxonomyRequest) (*models.Species, error)
+	DeleteSpecies(id uint) error
+	GetBreeds(speciesID uint) ([]models.Breed, error)
+	CreateBreed(speciesID uint, req *models.TaxonomyRequest) (*models.Breed, error)
+	UpdateBreed(id uint, req *models.TaxonomyRequest) (*models.Breed, error)
+	DeleteBreed(id uint) error
+}
+
+type RoleService interface {
+	GrantRole(ctx context.Context, userID uint, role models.Role) (*models.User, error)
+	RevokeRole(ctx context.Context, userID uint, role models.Role) (*models.User, error)
+}
+
+type AuthService interface {
+	Register(ctx context.Context, req *models.RegisterRequest) (*models.User, error)
+	Login(req *models.LoginRequest) (*models.LoginResponse, error)
+	CurrentUser(ctx context.Context) (*models.User, error)
+	// Authenticate verifies an access token and resolves it to the user's
+	// current roles, so revoked roles and deleted users lose access at once
+	// rather than when the token expires.
+	Authenticate(token string) (*auth.Principal, error)
+}
+
+type BucketService interface {
+	GetCart(ctx context.Context, buyerID uint) (*models.Cart, error)
+	AddToCart(ctx context.Context, buyerID uint, petID uint) (*models.Cart, error)
+	RemoveFromCart(ctx context.Context, buyerID uint, petID uint) (*models.Cart, error)
+}
+
+type FavouriteService interface {
+	GetFavourites(ctx context.Context, buyerID uint) (*models.Favourites, error)
+	AddFavourite(ctx context.Context, buyerID uint, petID uint) (*models.Favourites, error)
+	RemoveFavourite(ctx context.Context, buyerID uint, petID uint) (*models.Favourites, error)
+}
+
+type OrderService interface {
+	Checkout(ctx context.Context, buyerID uint) (*models.Order, error)
+	GetOrder(ctx context.Context, id uint) (*models.Order, error)
+	GetBuyerOrders(ctx context.Context, buyerID uint, page models.PageRequest) ([]models.Order, *models.PageInfo, error)
+	GetSellerOrders(ctx context.Context, sellerID uint, page models.PageRequest) ([]models.Order, *models.PageInfo, error)
+	CancelOrder(ctx context.Context, id uint, note string) (*models.Order, error)
+	UpdateOrderStatus(ctx context.Context, id uint, req *models.UpdateOrderStatusRequest) (*models.Order, error)
+}
+
+type PaymentService interface {
+	PayOrder(ctx context.Context, orderID uint) (*models.Payment, error)
+	RefundOrder(ctx context.Context, orderID uint) (*models.Payment, error)
+	GetOrderPayments(ctx context.Context, orderID uint) ([]models.Payment, error)
+	HandleWebhook(payload []byte, signature string) error
+}
+
+// PurgeService removes soft-deleted pets and users for good.
+type PurgeService interface {
+	// Purge removes what was deleted before cutoff and returns how many
+	// pets and users went.
+	Purge(ctx context.Context, cutoff time.Time) (int, int64, error)
+	Run(ctx context.Context, interval, retention time.Duration)
 }
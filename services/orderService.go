@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"petstore-api/auth"
+	"petstore-api/models"
+	"petstore-api/repositories"
+
+	"gorm.io/gorm"
+)
+
+type orderService struct {
+	orderRepo  repositories.OrderRepository
+	bucketRepo repositories.UserItemRepository
+	buyerRepo  repositories.UserRepository
+	petRepo    repositories.PetRepository
+	petAudit   petStatusAudit
+}
+
+func NewOrderService(orderRepo repositories.OrderRepository, bucketRepo repositories.UserItemRepository, buyerRepo repositories.UserRepository, petRepo repositories.PetRepository, auditRepo repositories.AuditRepository) OrderService {
+	return &orderService{
+		orderRepo:  orderRepo,
+		bucketRepo: bucketRepo,
+		buyerRepo:  buyerRepo,
+		petRepo:    petRepo,
+		petAudit:   petStatusAudit{petRepo: petRepo, auditRepo: auditRepo},
+	}
+}
+
+// Checkout turns the pets currently shown in the buyer's cart into a pending
+// order. Pets that were already hidden from the cart are dropped; a pet that
+// is taken between reading the cart and placing the order fails the whole
+// checkout so the buyer can review the cart again.
+func (s *orderService) Checkout(ctx context.Context, buyerID uint) (*models.Order, error) {
+	if err := authorizeUser(ctx, buyerID); err != nil {
+		return nil, err
+	}
+
+	_, err := s.buyerRepo.GetByID(buyerID, false)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("buyer not found")
+		}
+		return nil, err
+	}
+
+	cartIDs, err := s.bucketRepo.GetPetIDs(buyerID)
+	if err != nil {
+		return nil, err
+	}
+	pets, err := s.petRepo.GetByIDs(cartIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var petIDs []uint
+	before := make(map[uint]*models.PetSnapshot)
+	for i, pet := range pets {
+		if pet.Status == models.PetStatusListed {
+			petIDs = append(petIDs, pet.ID)
+			before[pet.ID] = models.NewPetSnapshot(&pets[i])
+		}
+	}
+	if len(petIDs) == 0 {
+		return nil, errors.New("cart is empty")
+	}
+
+	order, err := s.orderRepo.CreateFromPets(buyerID, petIDs)
+	if err != nil {
+		if errors.Is(err, repositories.ErrPetUnavailable) {
+			return nil, errors.New("some pets in the cart are no longer available")
+		}
+		if errors.Is(err, models.ErrCurrencyMismatch) {
+			return nil, errors.New("cart contains pets priced in different currencies")
+		}
+		return nil, err
+	}
+	s.petAudit.record(ctx, before)
+
+	// The order is already committed at this point, so a failure to empty
+	// the cart must not be reported as a failed checkout.
+	if err := s.bucketRepo.Clear(buyerID); err != nil {
+		log.Printf("failed to clear cart of buyer %d after order %d: %v", buyerID, order.ID, err)
+	}
+
+	return s.orderRepo.GetByID(order.ID)
+}
+
+func (s *orderService) GetOrder(ctx context.Context, id uint) (*models.Order, error) {
+	order, err := s.getOrder(id)
+	if err != nil {
+		return nil, err
+	}
+
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, ErrForbidden
+	}
+	if principal.UserID != order.BuyerID && !order.HasSeller(principal.UserID) && !principal.IsAdmin() {
+		return nil, ErrForbidden
+	}
+
+	return order, nil
+}
+
+func (s *orderService) GetBuyerOrders(ctx context.Context, buyerID uint, page models.PageRequest) ([]models.Order, *models.PageInfo, error) {
+	if err := authorizeUser(ctx, buyerID); err != nil {
+		return nil, nil, err
+	}
+	return s.orderRepo.GetByBuyerID(buyerID, page)
+}
+
+func (s *orderService) GetSellerOrders(ctx context.Context, sellerID uint, page models.PageRequest) ([]models.Order, *models.PageInfo, error) {
+	if err := authorizeUser(ctx, sellerID); err != nil {
+		return nil, nil, err
+	}
+	return s.orderRepo.GetBySellerID(sellerID, page)
+}
+
+func (s *orderService) CancelOrder(ctx context.Context, id uint, note string) (*models.Order, error) {
+	return s.UpdateOrderStatus(ctx, id, &models.UpdateOrderStatusRequest{
+		Status: models.OrderStatusCancelled,
+		Note:   note,
+	})
+}
+
+// UpdateOrderStatus moves an order along its lifecycle. Buyers cancel their
+// own pending orders and confirm completion, sellers confirm the hand-over
+// of orders that only contain their pets, and payments and refunds are left
+// to admins and the payment provider.
+func (s *orderService) UpdateOrderStatus(ctx context.Context, id uint, req *models.UpdateOrderStatusRequest) (*models.Order, error) {
+	if !req.Status.Valid() {
+		return nil, errors.New("invalid order status")
+	}
+
+	order, err := s.getOrder(id)
+	if err != nil {
+		return nil, err
+	}
+
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, ErrForbidden
+	}
+	if !principal.IsAdmin() {
+		allowed := false
+		switch req.Status {
+		case models.OrderStatusCancelled, models.OrderStatusCompleted:
+			allowed = principal.UserID == order.BuyerID
+		case models.OrderStatusHandedOver:
+			allowed = order.SoldOnlyBy(principal.UserID)
+		}
+		if !allowed {
+			return nil, ErrForbidden
+		}
+	}
+
+	return s.transition(ctx, order, req.Status, &principal.UserID, req.Note)
+}
+
+func (s *orderService) transition(ctx context.Context, order *models.Order, to models.OrderStatus, changedBy *uint, note string) (*models.Order, error) {
+	var before map[uint]*models.PetSnapshot
+	if to.ReleasesPets() || to.SellsPets() {
+		before = s.petAudit.snapshot(order.PetIDs())
+	}
+
+	order, err := s.orderRepo.Transition(order.ID, to, changedBy, note)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInvalidTransition) {
+			return nil, errors.New("order cannot move to this status")
+		}
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("order not found")
+		}
+		return nil, err
+	}
+	s.petAudit.record(ctx, before)
+	return order, nil
+}
+
+func (s *orderService) getOrder(id uint) (*models.Order, error) {
+	order, err := s.orderRepo.GetByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("order not found")
+		}
+		return nil, err
+	}
+	return order, nil
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"petstore-api/auth"
+	"petstore-api/models"
+	"petstore-api/payments"
+	"petstore-api/repositories"
+
+	"gorm.io/gorm"
+)
+
+type paymentService struct {
+	paymentRepo   repositories.PaymentRepository
+	orderRepo     repositories.OrderRepository
+	provider      payments.Provider
+	webhookSecret []byte
+	petAudit      petStatusAudit
+}
+
+func NewPaymentService(paymentRepo repositories.PaymentRepository, orderRepo repositories.OrderRepository, petRepo repositories.PetRepository, auditRepo repositories.AuditRepository, provider payments.Provider, webhookSecret []byte) PaymentService {
+	return &paymentService{
+		paymentRepo:   paymentRepo,
+		orderRepo:     orderRepo,
+		provider:      provider,
+		webhookSecret: webhookSecret,
+		petAudit:      petStatusAudit{petRepo: petRepo, auditRepo: auditRepo},
+	}
+}
+
+// PayOrder starts paying for a pending order. The provider reports the
+// outcome through the payments webhook, which moves the order to paid.
+func (s *paymentService) PayOrder(ctx context.Context, orderID uint) (*models.Payment, error) {
+	order, err := s.getOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeUser(ctx, order.BuyerID); err != nil {
+		return nil, err
+	}
+	if order.Status != models.OrderStatusPending {
+		return nil, errors.New("only pending orders can be paid")
+	}
+
+	intent, err := s.provider.CreateIntent(ctx, order.Total, orderReference(order.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	payment := &models.Payment{
+		OrderID:  order.ID,
+		Provider: s.provider.Name(),
+		IntentID: intent.ID,
+		Amount:   intent.Amount,
+		Status:   models.PaymentStatusProcessing,
+	}
+	// The order is checked again under its row lock: two concurrent calls
+	// may both have got this far, but only one of them gets to capture.
+	if err := s.paymentRepo.CreateProcessing(payment); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("order not found")
+		}
+		return nil, err
+	}
+
+	if err := s.provider.Capture(ctx, intent.ID); err != nil {
+		// No webhook will follow a capture that never started, so the
+		// attempt is failed here or the order could never be paid again.
+		if markErr := s.paymentRepo.MarkFailed(payment.ID); markErr != nil {
+			log.Printf("failed to mark payment %s for order %d failed: %v", payment.IntentID, order.ID, markErr)
+		}
+		return nil, err
+	}
+
+	payment.ClientSecret = intent.ClientSecret
+	return payment, nil
+}
+
+// RefundOrder asks the provider to refund the order's successful payment.
+// The order becomes refunded once the provider confirms through the webhook.
+func (s *paymentService) RefundOrder(ctx context.Context, orderID uint) (*models.Payment, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok || !principal.IsAdmin() {
+		return nil, ErrForbidden
+	}
+
+	order, err := s.getOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if !order.Status.CanTransitionTo(models.OrderStatusRefunded) {
+		return nil, errors.New("only paid or handed over orders can be refunded")
+	}
+
+	existing, err := s.paymentRepo.GetByOrderID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range existing {
+		if existing[i].Status == models.PaymentStatusSucceeded {
+			if err := s.provider.Refund(ctx, existing[i].IntentID); err != nil {
+				return nil, err
+			}
+			return &existing[i], nil
+		}
+	}
+
+	return nil, errors.New("order has no successful payment to refund")
+}
+
+func (s *paymentService) GetOrderPayments(ctx context.Context, orderID uint) ([]models.Payment, error) {
+	order, err := s.getOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeUser(ctx, order.BuyerID); err != nil {
+		return nil, err
+	}
+
+	return s.paymentRepo.GetByOrderID(orderID)
+}
+
+// HandleWebhook applies a signed provider event. Every event ID is applied
+// at most once, so providers may safely redeliver.
+func (s *paymentService) HandleWebhook(payload []byte, signature string) error {
+	if err := payments.VerifySignature(s.webhookSecret, payload, signature); err != nil {
+		return err
+	}
+
+	var event payments.Event
+	if err := json.Unmarshal(payload, &event); err != nil || event.ID == "" || event.IntentID == "" {
+		return errors.New("invalid webhook payload")
+	}
+
+	var status models.PaymentStatus
+	var orderStatus models.OrderStatus
+	switch event.Type {
+	case payments.EventPaymentSucceeded:
+		status, orderStatus = models.PaymentStatusSucceeded, models.OrderStatusPaid
+	case payments.EventPaymentFailed:
+		status = models.PaymentStatusFailed
+	case payments.EventPaymentRefunded:
+		status, orderStatus = models.PaymentStatusRefunded, models.OrderStatusRefunded
+	default:
+		// Unknown event types are acknowledged so the provider stops
+		// retrying them.
+		return nil
+	}
+
+	// A refund gives the order's pets back to the catalog, so they are read
+	// first for the audit trail.
+	var before map[uint]*models.PetSnapshot
+	if orderStatus.ReleasesPets() {
+		before = s.orderPets(event.IntentID)
+	}
+
+	payment, applied, orderMoved, err := s.paymentRepo.ApplyEvent(&models.PaymentEvent{
+		ID:       event.ID,
+		Type:     event.Type,
+		IntentID: event.IntentID,
+	}, status, orderStatus)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("payment not found")
+		}
+		if errors.Is(err, repositories.ErrEventOutOfOrder) {
+			// Acknowledged so the provider stops retrying it.
+			log.Printf("ignoring %s event %s for payment %s in status %s", event.Type, event.ID, payment.IntentID, payment.Status)
+			return nil
+		}
+		return err
+	}
+	if orderMoved && orderStatus.ReleasesPets() {
+		s.petAudit.record(context.Background(), before)
+	}
+	if !applied || event.Type != payments.EventPaymentSucceeded || orderMoved {
+		return nil
+	}
+
+	// A success that did not move the order to paid is not the order's
+	// payment: the order was cancelled in the meantime or another attempt
+	// already paid it. The money is sent straight back.
+	if err := s.provider.Refund(context.Background(), payment.IntentID); err != nil {
+		log.Printf("failed to refund payment %s for order %d: %v", payment.IntentID, payment.OrderID, err)
+	}
+
+	return nil
+}
+
+// orderPets snapshots the pets of the order paid for by an intent, or
+// returns nil when the payment or order cannot be read.
+func (s *paymentService) orderPets(intentID string) map[uint]*models.PetSnapshot {
+	payment, err := s.paymentRepo.GetByIntentID(intentID)
+	if err != nil {
+		return nil
+	}
+	order, err := s.orderRepo.GetByID(payment.OrderID)
+	if err != nil {
+		return nil
+	}
+	return s.petAudit.snapshot(order.PetIDs())
+}
+
+func (s *paymentService) getOrder(id uint) (*models.Order, error) {
+	order, err := s.orderRepo.GetByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("order not found")
+		}
+		return nil, err
+	}
+	return order, nil
+}
+
+func orderReference(orderID uint) string {
+	return fmt.Sprintf("order-%d", orderID)
+}
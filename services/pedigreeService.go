@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"petstore-api/models"
+	"petstore-api/repositories"
+
+	"gorm.io/gorm"
+)
+
+type pedigreeService struct {
+	pedigreeRepo repositories.PedigreeRepository
+	petRepo      repositories.PetRepository
+	auditRepo    repositories.AuditRepository
+}
+
+func NewPedigreeService(pedigreeRepo repositories.PedigreeRepository, petRepo repositories.PetRepository, auditRepo repositories.AuditRepository) PedigreeService {
+	return &pedigreeService{
+		pedigreeRepo: pedigreeRepo,
+		petRepo:      petRepo,
+		auditRepo:    auditRepo,
+	}
+}
+
+// GetPedigree hides the pedigree of drafts and withdrawn pets from everyone
+// but their seller and admins.
+func (s *pedigreeService) GetPedigree(ctx context.Context, petID uint, generations int) (*models.PedigreeNode, error) {
+	pet, err := s.petRepo.GetByID(petID, false)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("pet not found")
+		}
+		return nil, err
+	}
+	if !pet.Status.Public() && authorizeUser(ctx, pet.SellerID) != nil {
+		return nil, errors.New("pet not found")
+	}
+
+	tree, err := s.pedigreeRepo.Ancestry(petID, generations)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("pet not found")
+		}
+		return nil, err
+	}
+	return tree, nil
+}
+
+// SetParents replaces both parents of a pet. Parents must exist, be of the
+// pet's species and must not already descend from the pet. Like UpdatePet it
+// fails with ErrVersionConflict when the pet is not at a version ifMatch
+// allows, or is changed by someone else before it is saved.
+func (s *pedigreeService) SetParents(ctx context.Context, petID uint, req *models.SetParentsRequest, ifMatch models.VersionMatch) (*models.Pet, error) {
+	pet, err := s.petRepo.GetByID(petID, false)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("pet not found")
+		}
+		return nil, err
+	}
+	if err := authorizeUser(ctx, pet.SellerID); err != nil {
+		return nil, err
+	}
+	if !ifMatch.Matches(pet.Version) {
+		return nil, repositories.ErrVersionConflict
+	}
+	if err := req.Validate(petID); err != nil {
+		return nil, err
+	}
+
+	var verr models.ValidationError
+	for _, parent := range []struct {
+		field string
+		ref   *models.ParentRef
+	}{{"sire", req.Sire}, {"dam", req.Dam}} {
+		if parent.ref == nil {
+			continue
+		}
+		problem, err := s.checkParent(pet, parent.ref)
+		if err != nil {
+			return nil, err
+		}
+		if problem != "" {
+			verr.Add(parent.field, problem)
+		}
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	before := models.NewPetSnapshot(pet)
+	pet.SireID, pet.SireEntryID = refIDs(req.Sire)
+	pet.DamID, pet.DamEntryID = refIDs(req.Dam)
+	if err := s.pedigreeRepo.SetParents(pet); err != nil {
+		if errors.Is(err, repositories.ErrPedigreeCycle) {
+			return nil, errors.New("a pet cannot be its own ancestor")
+		}
+		return nil, err
+	}
+	recordAudit(ctx, s.auditRepo, models.AuditEntry{EntityType: models.AuditEntityPet, EntityID: pet.ID, Action: models.AuditActionUpdate}, before, models.NewPetSnapshot(pet))
+
+	return pet, nil
+}
+
+// checkParent returns what is wrong with ref as a parent of pet, or an
+// empty string if nothing is.
+func (s *pedigreeService) checkParent(pet *models.Pet, ref *models.ParentRef) (string, error) {
+	var species string
+	if ref.PetID != nil {
+		parent, err := s.petRepo.GetByID(*ref.PetID, false)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return "pet not found", nil
+			}
+			return "", err
+		}
+		species = parent.Species
+	} else {
+		entry, err := s.pedigreeRepo.GetEntryByID(*ref.EntryID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return "pedigree entry not found", nil
+			}
+			return "", err
+		}
+		species = entry.Species
+	}
+
+	if !strings.EqualFold(species, pet.Species) {
+		return "must be of species " + pet.Species, nil
+	}
+	return "", nil
+}
+
+// refIDs splits a parent reference into the pet and pedigree entry columns.
+func refIDs(ref *models.ParentRef) (petID *uint, entryID *uint) {
+	if ref == nil {
+		return nil, nil
+	}
+	return ref.PetID, ref.EntryID
+}
+
+func (s *pedigreeService) CreateEntry(req *models.CreatePedigreeEntryRequest) (*models.PedigreeEntry, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	var verr models.ValidationError
+	for _, parent := range []struct {
+		field string
+		id    *uint
+	}{{"sire_id", req.SireID}, {"dam_id", req.DamID}} {
+		if parent.id == nil {
+			continue
+		}
+		entry, err := s.pedigreeRepo.GetEntryByID(*parent.id)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				verr.Add(parent.field, "pedigree entry not found")
+				continue
+			}
+			return nil, err
+		}
+		if !strings.EqualFold(entry.Species, req.Species) {
+			verr.Add(parent.field, "must be of species "+req.Species)
+		}
+	}
+	if err := verr.Err(); err != nil {
+		return nil, err
+	}
+
+	entry := &models.PedigreeEntry{
+		Name:               req.Name,
+		Species:            req.Species,
+		Breed:              req.Breed,
+		Registry:           req.Registry,
+		RegistrationNumber: req.RegistrationNumber,
+		SireID:             req.SireID,
+		DamID:              req.DamID,
+	}
+	if err := s.pedigreeRepo.CreateEntry(entry); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func (s *pedigreeService) GetEntry(id uint) (*models.PedigreeEntry, error) {
+	entry, err := s.pedigreeRepo.GetEntryByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("pedigree entry not found")
+		}
+		return nil, err
+	}
+	return entry, nil
+}
@@ -1,74 +1,366 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strings"
 
+	"petstore-api/auth"
 	"petstore-api/models"
 	"petstore-api/repositories"
+	"petstore-api/storage"
 
 	"gorm.io/gorm"
 )
 
 type petService struct {
-	petRepo    repositories.PetRepository
-	sellerRepo repositories.UserRepository
+	petRepo       repositories.PetRepository
+	userRepo      repositories.UserRepository
+	favouriteRepo repositories.FavouriteRepository
+	photoRepo     repositories.PhotoRepository
+	taxonomyRepo  repositories.TaxonomyRepository
+	auditRepo     repositories.AuditRepository
+	store         storage.BlobStore
 }
 
-func NewPetService(petRepo repositories.PetRepository, sellerRepo repositories.UserRepository) PetService {
+func NewPetService(petRepo repositories.PetRepository, userRepo repositories.UserRepository, favouriteRepo repositories.FavouriteRepository, photoRepo repositories.PhotoRepository, taxonomyRepo repositories.TaxonomyRepository, auditRepo repositories.AuditRepository, store storage.BlobStore) PetService {
 	return &petService{
-		petRepo:    petRepo,
-		sellerRepo: sellerRepo,
+		petRepo:       petRepo,
+		userRepo:      userRepo,
+		favouriteRepo: favouriteRepo,
+		photoRepo:     photoRepo,
+		taxonomyRepo:  taxonomyRepo,
+		auditRepo:     auditRepo,
+		store:         store,
 	}
 }
 
-func (s *petService) GetAllPets(includeSeller bool, sellerID *uint) ([]models.Pet, error) {
-	return s.petRepo.GetAll(includeSeller, sellerID)
+func (s *petService) GetAllPets(ctx context.Context, includeSeller bool, filter models.PetFilter, page models.PageRequest) ([]models.Pet, *models.PageInfo, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if err := scopeStatuses(ctx, &filter); err != nil {
+		return nil, nil, err
+	}
+	petRepo, err := s.pets(ctx, filter.IncludeDeleted)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pets, info, err := petRepo.GetAll(includeSeller, filter, page)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.attachDetails(pets); err != nil {
+		return nil, nil, err
+	}
+
+	return pets, info, nil
+}
+
+func (s *petService) SearchPets(ctx context.Context, text string, filter models.PetFilter, page models.PageRequest) ([]models.PetSearchResult, *models.PageInfo, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		verr := &models.ValidationError{}
+		verr.Add("q", "must not be empty")
+		return nil, nil, verr
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if err := scopeStatuses(ctx, &filter); err != nil {
+		return nil, nil, err
+	}
+	petRepo, err := s.pets(ctx, filter.IncludeDeleted)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results, info, err := petRepo.Search(text, filter, page)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pets := make([]models.Pet, len(results))
+	for i := range results {
+		pets[i] = results[i].Pet
+	}
+	if err := s.attachDetails(pets); err != nil {
+		return nil, nil, err
+	}
+	for i := range results {
+		results[i].Pet = pets[i]
+	}
+
+	return results, info, nil
+}
+
+// GetPetFacets counts the catalog for the storefront sidebar. Buckets that
+// are not given fall back to the defaults, with default price bounds in the
+// filter's currency.
+func (s *petService) GetPetFacets(ctx context.Context, filter models.PetFilter, options models.PetFacetOptions) (*models.PetFacets, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	if err := scopeStatuses(ctx, &filter); err != nil {
+		return nil, err
+	}
+	petRepo, err := s.pets(ctx, filter.IncludeDeleted)
+	if err != nil {
+		return nil, err
+	}
+
+	options.StatusScope = visibleStatuses(ctx, filter)
+	if options.PriceBounds == nil {
+		currency := filter.Currency
+		if currency == "" {
+			currency = models.DefaultCurrency
+		}
+		for _, amount := range models.DefaultPriceBounds {
+			options.PriceBounds = append(options.PriceBounds, models.FromMajorUnits(amount, currency))
+		}
+	}
+	if options.AgeBounds == nil {
+		options.AgeBounds = models.DefaultAgeBounds
+	}
+
+	return petRepo.Facets(filter, options)
+}
+
+// ExportPets streams the pets straight from the repository. Favourite
+// counts and photos live in other tables and are left out.
+func (s *petService) ExportPets(ctx context.Context, filter models.PetFilter, fn func(pet *models.Pet) error) error {
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+	if err := scopeStatuses(ctx, &filter); err != nil {
+		return err
+	}
+	petRepo, err := s.pets(ctx, filter.IncludeDeleted)
+	if err != nil {
+		return err
+	}
+
+	return petRepo.Export(filter, fn)
 }
 
-func (s *petService) GetPetByID(id uint, includeSeller bool) (*models.Pet, error) {
-	pet, err := s.petRepo.GetByID(id, includeSeller)
+// GetPetByID hides drafts and withdrawn pets from everyone but their seller
+// and admins, as if they did not exist.
+func (s *petService) GetPetByID(ctx context.Context, id uint, includeSeller bool, includeDeleted bool) (*models.Pet, error) {
+	petRepo, err := s.pets(ctx, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+
+	pet, err := petRepo.GetByID(id, includeSeller)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, errors.New("pet not found")
 		}
 		return nil, err
 	}
+	if !pet.Status.Public() && authorizeUser(ctx, pet.SellerID) != nil {
+		return nil, errors.New("pet not found")
+	}
+
+	pets := []models.Pet{*pet}
+	if err := s.attachDetails(pets); err != nil {
+		return nil, err
+	}
+
+	return &pets[0], nil
+}
+
+func (s *petService) CreatePet(ctx context.Context, req *models.CreatePetRequest) (*models.Pet, error) {
+	if principal, ok := auth.PrincipalFromContext(ctx); ok && req.SellerID == 0 {
+		req.SellerID = principal.UserID
+	}
+	pet, err := newPet(req, s.lookupTaxonomy)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeUser(ctx, req.SellerID); err != nil {
+		return nil, err
+	}
+	if err := s.checkSeller(req.SellerID); err != nil {
+		return nil, err
+	}
+
+	err = s.petRepo.Create(pet, changedBy(ctx))
+	if err != nil {
+		return nil, err
+	}
+	s.audit(ctx, pet.ID, models.AuditActionCreate, nil, models.NewPetSnapshot(pet))
+
 	return pet, nil
 }
 
-func (s *petService) CreatePet(req *models.CreatePetRequest) (*models.Pet, error) {
+// taxonomyLookup maps a species and breed to their canonical names.
+type taxonomyLookup func(species, breed string) (string, string, error)
+
+func (s *petService) lookupTaxonomy(species, breed string) (string, string, error) {
+	return normalizeTaxonomy(s.taxonomyRepo, species, breed)
+}
+
+// newPet applies the rules every new pet has to pass, whether it is created
+// on its own or imported, and builds the pet. It does not check who may
+// create it.
+func newPet(req *models.CreatePetRequest, taxonomy taxonomyLookup) (*models.Pet, error) {
 	if req.Name == "" || req.Species == "" || req.SellerID == 0 {
 		return nil, errors.New("name, species, and seller_id are required")
 	}
+	if req.Age < 0 {
+		return nil, errors.New("age must not be negative")
+	}
 
-	_, err := s.sellerRepo.GetByID(req.SellerID, false)
+	price := req.Price.Normalize()
+	if err := price.Validate(); err != nil {
+		return nil, err
+	}
+
+	species, breed, err := taxonomy(req.Species, req.Breed)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, errors.New("seller not found")
-		}
 		return nil, err
 	}
 
-	pet := &models.Pet{
+	status := req.Status
+	if status == "" {
+		status = models.PetStatusListed
+	}
+	if status != models.PetStatusDraft && status != models.PetStatusListed {
+		return nil, errors.New("status must be draft or listed")
+	}
+
+	return &models.Pet{
 		Name:        req.Name,
-		Species:     req.Species,
-		Breed:       req.Breed,
+		Species:     species,
+		Breed:       breed,
 		Age:         req.Age,
-		Price:       req.Price,
+		Price:       price,
 		Description: req.Description,
-		Available:   req.Available,
+		Status:      status,
 		SellerID:    req.SellerID,
+	}, nil
+}
+
+// ImportPets validates every row with the rules of CreatePet and, unless it
+// is a dry run, stores the valid ones for the seller. In all-or-nothing mode
+// a single failed row keeps every pet out.
+func (s *petService) ImportPets(ctx context.Context, sellerID uint, rows []models.PetImportRow, options models.PetImportOptions) (*models.PetImportReport, error) {
+	if options.Mode == "" {
+		options.Mode = models.PetImportAllOrNothing
+	}
+	if !options.Mode.Valid() {
+		return nil, errors.New("mode must be all_or_nothing or partial")
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("import contains no pets")
+	}
+	if len(rows) > models.MaxPetImportRows {
+		return nil, fmt.Errorf("import must not contain more than %d pets", models.MaxPetImportRows)
+	}
+
+	if err := authorizeUser(ctx, sellerID); err != nil {
+		return nil, err
+	}
+	if err := s.checkSeller(sellerID); err != nil {
+		return nil, err
+	}
+
+	// Rows tend to repeat the same few species and breeds, so each pair is
+	// only looked up once. Lookup failures other than unknown names abort
+	// the import instead of being blamed on a row.
+	type taxonomyKey struct{ species, breed string }
+	type taxonomyResult struct {
+		species, breed string
+		err            error
+	}
+	known := make(map[taxonomyKey]taxonomyResult)
+	var lookupErr error
+	lookup := func(species, breed string) (string, string, error) {
+		key := taxonomyKey{strings.ToLower(species), strings.ToLower(breed)}
+		result, ok := known[key]
+		if !ok {
+			result.species, result.breed, result.err = s.lookupTaxonomy(species, breed)
+			var verr *models.ValidationError
+			if result.err != nil && !errors.As(result.err, &verr) {
+				lookupErr = result.err
+				return "", "", result.err
+			}
+			known[key] = result
+		}
+		return result.species, result.breed, result.err
+	}
+
+	report := &models.PetImportReport{
+		DryRun: options.DryRun,
+		Mode:   options.Mode,
+		Rows:   len(rows),
+		Errors: []models.PetImportRowError{},
+		Pets:   []models.ImportedPet{},
+	}
+	var pets []models.Pet
+	var lines []int
+	for _, row := range rows {
+		if row.Errors != nil && row.Errors.HasErrors() {
+			report.AddError(row.Line, row.Errors)
+			continue
+		}
+
+		req := row.Pet
+		if req.SellerID == 0 {
+			req.SellerID = sellerID
+		}
+		if req.SellerID != sellerID {
+			report.AddError(row.Line, errors.New("seller_id must match the seller being imported for"))
+			continue
+		}
+
+		pet, err := newPet(&req, lookup)
+		if lookupErr != nil {
+			return nil, lookupErr
+		}
+		if err != nil {
+			report.AddError(row.Line, err)
+			continue
+		}
+		pets = append(pets, *pet)
+		lines = append(lines, row.Line)
+	}
+	report.Valid = len(pets)
+
+	skip := options.DryRun || len(pets) == 0 ||
+		(options.Mode == models.PetImportAllOrNothing && len(report.Errors) > 0)
+	if skip {
+		return report, nil
 	}
 
-	err = s.petRepo.Create(pet)
+	failed, err := s.petRepo.CreateMany(pets, changedBy(ctx), options.Mode == models.PetImportPartial)
 	if err != nil {
 		return nil, err
 	}
+	for i, pet := range pets {
+		if err, ok := failed[i]; ok {
+			report.AddError(lines[i], err)
+			continue
+		}
+		if pet.ID != 0 {
+			report.Pets = append(report.Pets, models.ImportedPet{Line: lines[i], PetID: pet.ID})
+			s.audit(ctx, pet.ID, models.AuditActionCreate, nil, models.NewPetSnapshot(&pet))
+		}
+	}
+	report.Imported = len(report.Pets)
+	report.SortErrors()
 
-	return pet, nil
+	return report, nil
 }
 
-func (s *petService) UpdatePet(id uint, req *models.UpdatePetRequest) (*models.Pet, error) {
+// UpdatePet fails with ErrVersionConflict when the pet is not at a version
+// ifMatch allows, or is changed by someone else before it is saved.
+func (s *petService) UpdatePet(ctx context.Context, id uint, req *models.UpdatePetRequest, ifMatch models.VersionMatch) (*models.Pet, error) {
 	pet, err := s.petRepo.GetByID(id, false)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -77,34 +369,57 @@ func (s *petService) UpdatePet(id uint, req *models.UpdatePetRequest) (*models.P
 		return nil, err
 	}
 
+	if err := authorizeUser(ctx, pet.SellerID); err != nil {
+		return nil, err
+	}
+	if !ifMatch.Matches(pet.Version) {
+		return nil, repositories.ErrVersionConflict
+	}
+	before := models.NewPetSnapshot(pet)
+
 	if req.Name != "" {
 		pet.Name = req.Name
 	}
-	if req.Species != "" {
-		pet.Species = req.Species
-	}
-	if req.Breed != "" {
-		pet.Breed = req.Breed
+	if req.Species != "" || req.Breed != "" {
+		// The breed is checked against the new species, so changing only
+		// the species of a pet with a breed must keep the pair valid.
+		species, breed := pet.Species, pet.Breed
+		if req.Species != "" {
+			species = req.Species
+		}
+		if req.Breed != "" {
+			breed = req.Breed
+		}
+		species, breed, err = normalizeTaxonomy(s.taxonomyRepo, species, breed)
+		if err != nil {
+			return nil, err
+		}
+		pet.Species, pet.Breed = species, breed
 	}
-	if req.Age > 0 {
-		pet.Age = req.Age
+	if req.Age != nil {
+		if *req.Age < 0 {
+			return nil, errors.New("age must not be negative")
+		}
+		pet.Age = *req.Age
 	}
-	if req.Price > 0 {
-		pet.Price = req.Price
+	if req.Price != nil {
+		price := req.Price.Normalize()
+		if err := price.Validate(); err != nil {
+			return nil, err
+		}
+		pet.Price = price
 	}
 	if req.Description != "" {
 		pet.Description = req.Description
 	}
-	if req.Available != nil {
-		pet.Available = *req.Available
-	}
 
 	if req.SellerID != 0 && req.SellerID != pet.SellerID {
-		_, err := s.sellerRepo.GetByID(req.SellerID, false)
-		if err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return nil, errors.New("seller not found")
-			}
+		// Handing a pet over to another seller is an admin operation; an
+		// owner must not be able to push listings onto someone else.
+		if principal, _ := auth.PrincipalFromContext(ctx); !principal.IsAdmin() {
+			return nil, ErrForbidden
+		}
+		if err := s.checkSeller(req.SellerID); err != nil {
 			return nil, err
 		}
 		pet.SellerID = req.SellerID
@@ -114,12 +429,261 @@ func (s *petService) UpdatePet(id uint, req *models.UpdatePetRequest) (*models.P
 	if err != nil {
 		return nil, err
 	}
+	s.audit(ctx, pet.ID, models.AuditActionUpdate, before, models.NewPetSnapshot(pet))
+
+	pets := []models.Pet{*pet}
+	if err := s.attachDetails(pets); err != nil {
+		return nil, err
+	}
+
+	return &pets[0], nil
+}
+
+// ChangeStatus applies a seller's status change. Reserved and sold belong to
+// orders, so they can neither be set nor left here. Like UpdatePet it fails
+// with ErrVersionConflict when the pet is not at a version ifMatch allows,
+// or is changed by someone else before the status is saved.
+func (s *petService) ChangeStatus(ctx context.Context, id uint, req *models.UpdatePetStatusRequest, ifMatch models.VersionMatch) (*models.Pet, error) {
+	if !req.Status.Valid() {
+		return nil, errors.New("invalid pet status")
+	}
+
+	pet, err := s.getOwnedPet(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !ifMatch.Matches(pet.Version) {
+		return nil, repositories.ErrVersionConflict
+	}
+	before := models.NewPetSnapshot(pet)
+
+	pet, err = s.petRepo.Transition(pet.ID, pet.Version, req.Status, changedBy(ctx), req.Note)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInvalidTransition) {
+			return nil, errors.New("pet cannot move to this status")
+		}
+		return nil, err
+	}
+	s.audit(ctx, pet.ID, models.AuditActionUpdate, before, models.NewPetSnapshot(pet))
+
+	pets := []models.Pet{*pet}
+	if err := s.attachDetails(pets); err != nil {
+		return nil, err
+	}
+
+	return &pets[0], nil
+}
+
+func (s *petService) GetStatusHistory(ctx context.Context, id uint) ([]models.PetStatusHistory, error) {
+	if _, err := s.getOwnedPet(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.petRepo.GetStatusHistory(id)
+}
+
+// GetVersions lists the audit history of a pet. The history outlives the
+// pet, so a deleted pet's history is still shown to its last seller and
+// admins.
+func (s *petService) GetVersions(ctx context.Context, id uint) ([]models.AuditEntry, error) {
+	entries, err := s.auditRepo.GetHistory(models.AuditEntityPet, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var sellerID uint
+	pet, err := s.petRepo.GetByID(id, false)
+	switch {
+	case err == nil:
+		sellerID = pet.SellerID
+	case err == gorm.ErrRecordNotFound && len(entries) > 0:
+		var last models.PetSnapshot
+		if err := decodeSnapshot(entries[len(entries)-1].Snapshot, &last); err != nil {
+			return nil, err
+		}
+		sellerID = last.SellerID
+	case err == gorm.ErrRecordNotFound:
+		return nil, errors.New("pet not found")
+	default:
+		return nil, err
+	}
+
+	if err := authorizeUser(ctx, sellerID); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// RestoreVersion puts back the fields a pet had in an earlier version. The
+// status is left alone, since it follows the listing lifecycle and orders,
+// and moving the pet back to another seller takes an admin as in
+// UpdatePet. Deleted pets cannot be restored. Like UpdatePet it fails with
+// ErrVersionConflict when the pet is not at a version ifMatch allows.
+func (s *petService) RestoreVersion(ctx context.Context, id uint, version int, ifMatch models.VersionMatch) (*models.Pet, error) {
+	pet, err := s.getOwnedPet(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !ifMatch.Matches(pet.Version) {
+		return nil, repositories.ErrVersionConflict
+	}
+
+	var snapshot models.PetSnapshot
+	entry, err := getAuditVersion(s.auditRepo, models.AuditEntityPet, id, version, &snapshot)
+	if err != nil {
+		return nil, err
+	}
+	before := models.NewPetSnapshot(pet)
+
+	if snapshot.SellerID != pet.SellerID {
+		if principal, _ := auth.PrincipalFromContext(ctx); !principal.IsAdmin() {
+			return nil, ErrForbidden
+		}
+		if err := s.checkSeller(snapshot.SellerID); err != nil {
+			return nil, err
+		}
+	}
+	// The taxonomy may have changed since, so the old names are checked
+	// again and brought up to date.
+	species, breed, err := normalizeTaxonomy(s.taxonomyRepo, snapshot.Species, snapshot.Breed)
+	if err != nil {
+		return nil, err
+	}
+
+	pet.Name = snapshot.Name
+	pet.Species, pet.Breed = species, breed
+	pet.Age = snapshot.Age
+	pet.Price = models.Money{Amount: snapshot.PriceAmount, Currency: snapshot.PriceCurrency}
+	pet.Description = snapshot.Description
+	pet.SellerID = snapshot.SellerID
+
+	if err := s.petRepo.Update(pet); err != nil {
+		return nil, err
+	}
+	recordAudit(ctx, s.auditRepo, models.AuditEntry{
+		EntityType:   models.AuditEntityPet,
+		EntityID:     id,
+		Action:       models.AuditActionRestore,
+		RestoredFrom: &entry.Version,
+	}, before, models.NewPetSnapshot(pet))
+
+	pets := []models.Pet{*pet}
+	if err := s.attachDetails(pets); err != nil {
+		return nil, err
+	}
+
+	return &pets[0], nil
+}
+
+// RestorePet brings back a deleted pet that has not been purged yet. Its
+// seller must still hold the seller role.
+func (s *petService) RestorePet(ctx context.Context, id uint) (*models.Pet, error) {
+	pet, err := s.petRepo.Unscoped().GetByID(id, false)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("pet not found")
+		}
+		return nil, err
+	}
+	if err := authorizeUser(ctx, pet.SellerID); err != nil {
+		return nil, err
+	}
+	if !pet.DeletedAt.Valid {
+		return nil, errors.New("pet is not deleted")
+	}
+	if err := s.checkSeller(pet.SellerID); err != nil {
+		return nil, err
+	}
+
+	if err := s.petRepo.Restore(id); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("pet is not deleted")
+		}
+		return nil, err
+	}
+	pet.DeletedAt = gorm.DeletedAt{}
+	s.audit(ctx, id, models.AuditActionUndelete, nil, models.NewPetSnapshot(pet))
 
+	pets := []models.Pet{*pet}
+	if err := s.attachDetails(pets); err != nil {
+		return nil, err
+	}
+
+	return &pets[0], nil
+}
+
+// pets returns the repository to read pets through, which also sees
+// deleted pets when an admin asks for them.
+func (s *petService) pets(ctx context.Context, includeDeleted bool) (repositories.PetRepository, error) {
+	if err := authorizeIncludeDeleted(ctx, includeDeleted); err != nil {
+		return nil, err
+	}
+	if includeDeleted {
+		return s.petRepo.Unscoped(), nil
+	}
+	return s.petRepo, nil
+}
+
+// audit records a change to a pet; see recordAudit.
+func (s *petService) audit(ctx context.Context, id uint, action models.AuditAction, before, after *models.PetSnapshot) {
+	recordAudit(ctx, s.auditRepo, models.AuditEntry{EntityType: models.AuditEntityPet, EntityID: id, Action: action}, before, after)
+}
+
+func (s *petService) getOwnedPet(ctx context.Context, id uint) (*models.Pet, error) {
+	pet, err := s.petRepo.GetByID(id, false)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("pet not found")
+		}
+		return nil, err
+	}
+	if err := authorizeUser(ctx, pet.SellerID); err != nil {
+		return nil, err
+	}
 	return pet, nil
 }
 
-func (s *petService) DeletePet(id uint) error {
-	_, err := s.petRepo.GetByID(id, false)
+// scopeStatuses shows only listed pets unless the filter asks for other
+// statuses. Drafts and withdrawn pets may only be listed by their seller,
+// who has to filter on their own seller_id, or by an admin.
+func scopeStatuses(ctx context.Context, filter *models.PetFilter) error {
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = []models.PetStatus{models.PetStatusListed}
+		return nil
+	}
+
+	for _, status := range filter.Statuses {
+		if status.Public() {
+			continue
+		}
+		principal, ok := auth.PrincipalFromContext(ctx)
+		if !ok {
+			return ErrForbidden
+		}
+		if !principal.IsAdmin() && (filter.SellerID == nil || *filter.SellerID != principal.UserID) {
+			return ErrForbidden
+		}
+		return nil
+	}
+	return nil
+}
+
+// visibleStatuses lists the statuses whose pets the caller may see under
+// filter, by the same rule as scopeStatuses.
+func visibleStatuses(ctx context.Context, filter models.PetFilter) []models.PetStatus {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	seesAll := ok && (principal.IsAdmin() || (filter.SellerID != nil && *filter.SellerID == principal.UserID))
+
+	var statuses []models.PetStatus
+	for _, status := range models.PetStatuses {
+		if seesAll || status.Public() {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+func (s *petService) DeletePet(ctx context.Context, id uint) error {
+	pet, err := s.petRepo.GetByID(id, false)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return errors.New("pet not found")
@@ -127,5 +691,58 @@ func (s *petService) DeletePet(id uint) error {
 		return err
 	}
 
-	return s.petRepo.Delete(id)
+	if err := authorizeUser(ctx, pet.SellerID); err != nil {
+		return err
+	}
+	// A reserved pet belongs to an open order, which has to be cancelled
+	// or completed first.
+	if pet.Status == models.PetStatusReserved {
+		return repositories.ErrPetReserved
+	}
+
+	// Photos are kept with the deleted pet so that it can be restored; the
+	// purge removes them along with the pet.
+	if err := s.petRepo.Delete(id); err != nil {
+		return err
+	}
+	s.audit(ctx, id, models.AuditActionDelete, models.NewPetSnapshot(pet), nil)
+	return nil
+}
+
+// checkSeller makes sure a pet is only ever assigned to a user holding the seller role.
+func (s *petService) checkSeller(userID uint) error {
+	user, err := s.userRepo.GetByID(userID, false)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ErrSellerNotFound
+		}
+		return err
+	}
+	if !user.HasRole(models.RoleSeller) {
+		return ErrNotSeller
+	}
+	return nil
+}
+
+// attachDetails fills in the favourite counts and photos, which live
+// outside the pets table.
+func (s *petService) attachDetails(pets []models.Pet) error {
+	if err := attachPhotos(s.photoRepo, s.store, pets); err != nil {
+		return err
+	}
+
+	ids := make([]uint, len(pets))
+	for i, pet := range pets {
+		ids[i] = pet.ID
+	}
+
+	counts, err := s.favouriteRepo.CountByPetIDs(ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range pets {
+		pets[i].FavouriteCount = counts[pets[i].ID]
+	}
+	return nil
 }
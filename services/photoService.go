@@ -0,0 +1,235 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+
+	"petstore-api/media"
+	"petstore-api/models"
+	"petstore-api/repositories"
+	"petstore-api/storage"
+
+	"gorm.io/gorm"
+)
+
+type photoService struct {
+	photoRepo      repositories.PhotoRepository
+	petRepo        repositories.PetRepository
+	store          storage.BlobStore
+	thumbnailWidth int
+}
+
+func NewPhotoService(photoRepo repositories.PhotoRepository, petRepo repositories.PetRepository, store storage.BlobStore, thumbnailWidth int) PhotoService {
+	return &photoService{
+		photoRepo:      photoRepo,
+		petRepo:        petRepo,
+		store:          store,
+		thumbnailWidth: thumbnailWidth,
+	}
+}
+
+// AddPhoto stores an uploaded image and a JPEG thumbnail of it. The type is
+// taken from the bytes, not from what the client claims.
+func (s *photoService) AddPhoto(ctx context.Context, petID uint, data []byte) (*models.PetPhoto, error) {
+	if _, err := s.getOwnedPet(ctx, petID); err != nil {
+		return nil, err
+	}
+
+	contentType, ext, err := media.Sniff(data)
+	if err != nil {
+		return nil, err
+	}
+	img, err := media.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	thumbnail, err := media.EncodeJPEG(media.Thumbnail(img, s.thumbnailWidth))
+	if err != nil {
+		return nil, err
+	}
+
+	name := randomName()
+	photo := &models.PetPhoto{
+		PetID:        petID,
+		Key:          fmt.Sprintf("pets/%d/%s%s", petID, name, ext),
+		ThumbnailKey: fmt.Sprintf("pets/%d/%s_thumb.jpg", petID, name),
+		ContentType:  contentType,
+		Size:         int64(len(data)),
+		Width:        img.Bounds().Dx(),
+		Height:       img.Bounds().Dy(),
+	}
+
+	if err := s.store.Put(ctx, photo.Key, bytes.NewReader(data), contentType); err != nil {
+		return nil, err
+	}
+	if err := s.store.Put(ctx, photo.ThumbnailKey, bytes.NewReader(thumbnail), "image/jpeg"); err != nil {
+		deleteBlobs(ctx, s.store, photo.Key)
+		return nil, err
+	}
+	if err := s.photoRepo.Create(photo); err != nil {
+		deleteBlobs(ctx, s.store, photo.Key, photo.ThumbnailKey)
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("pet not found")
+		}
+		return nil, err
+	}
+
+	setPhotoURLs(s.store, photo)
+	return photo, nil
+}
+
+// GetPhotos hides the photos of drafts and withdrawn pets from everyone but
+// their seller and admins, as GetPetByID hides the pets themselves.
+func (s *photoService) GetPhotos(ctx context.Context, petID uint) ([]models.PetPhoto, error) {
+	pet, err := s.getPet(petID)
+	if err != nil {
+		return nil, err
+	}
+	if !pet.Status.Public() && authorizeUser(ctx, pet.SellerID) != nil {
+		return nil, errors.New("pet not found")
+	}
+	return s.petPhotos(petID)
+}
+
+// DeletePhoto removes the photo and its blobs. If it was the primary photo,
+// the next one in order takes over.
+func (s *photoService) DeletePhoto(ctx context.Context, petID uint, photoID uint) error {
+	photo, err := s.getOwnedPhoto(ctx, petID, photoID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.photoRepo.Delete(photo); err != nil {
+		return err
+	}
+	deleteBlobs(ctx, s.store, photo.Key, photo.ThumbnailKey)
+	return nil
+}
+
+func (s *photoService) ReorderPhotos(ctx context.Context, petID uint, photoIDs []uint) ([]models.PetPhoto, error) {
+	if _, err := s.getOwnedPet(ctx, petID); err != nil {
+		return nil, err
+	}
+
+	if err := s.photoRepo.Reorder(petID, photoIDs); err != nil {
+		if errors.Is(err, repositories.ErrPhotoSetMismatch) {
+			return nil, errors.New("photo_ids must list every photo of the pet exactly once")
+		}
+		return nil, err
+	}
+	return s.petPhotos(petID)
+}
+
+func (s *photoService) SetPrimaryPhoto(ctx context.Context, petID uint, photoID uint) ([]models.PetPhoto, error) {
+	photo, err := s.getOwnedPhoto(ctx, petID, photoID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.photoRepo.SetPrimary(photo); err != nil {
+		return nil, err
+	}
+	return s.petPhotos(petID)
+}
+
+func (s *photoService) petPhotos(petID uint) ([]models.PetPhoto, error) {
+	photos, err := s.photoRepo.GetByPetIDs([]uint{petID})
+	if err != nil {
+		return nil, err
+	}
+	for i := range photos {
+		setPhotoURLs(s.store, &photos[i])
+	}
+	return photos, nil
+}
+
+func (s *photoService) getPet(id uint) (*models.Pet, error) {
+	pet, err := s.petRepo.GetByID(id, false)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("pet not found")
+		}
+		return nil, err
+	}
+	return pet, nil
+}
+
+// getOwnedPet loads a pet that the caller may manage photos of: its seller
+// or an admin.
+func (s *photoService) getOwnedPet(ctx context.Context, id uint) (*models.Pet, error) {
+	pet, err := s.getPet(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeUser(ctx, pet.SellerID); err != nil {
+		return nil, err
+	}
+	return pet, nil
+}
+
+func (s *photoService) getOwnedPhoto(ctx context.Context, petID uint, photoID uint) (*models.PetPhoto, error) {
+	if _, err := s.getOwnedPet(ctx, petID); err != nil {
+		return nil, err
+	}
+
+	photo, err := s.photoRepo.GetByID(photoID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("photo not found")
+		}
+		return nil, err
+	}
+	if photo.PetID != petID {
+		return nil, errors.New("photo not found")
+	}
+	return photo, nil
+}
+
+// attachPhotos loads the photos of all given pets with one query.
+func attachPhotos(photoRepo repositories.PhotoRepository, store storage.BlobStore, pets []models.Pet) error {
+	ids := make([]uint, len(pets))
+	for i, pet := range pets {
+		ids[i] = pet.ID
+	}
+
+	photos, err := photoRepo.GetByPetIDs(ids)
+	if err != nil {
+		return err
+	}
+
+	byPet := make(map[uint][]models.PetPhoto)
+	for _, photo := range photos {
+		setPhotoURLs(store, &photo)
+		byPet[photo.PetID] = append(byPet[photo.PetID], photo)
+	}
+	for i := range pets {
+		pets[i].Photos = byPet[pets[i].ID]
+	}
+	return nil
+}
+
+func setPhotoURLs(store storage.BlobStore, photo *models.PetPhoto) {
+	photo.URL = store.URL(photo.Key)
+	photo.ThumbnailURL = store.URL(photo.ThumbnailKey)
+}
+
+// deleteBlobs removes blobs whose database rows are already gone. A failure
+// only leaves an orphaned file behind, so it is logged rather than returned.
+func deleteBlobs(ctx context.Context, store storage.BlobStore, keys ...string) {
+	for _, key := range keys {
+		if err := store.Delete(ctx, key); err != nil {
+			log.Printf("failed to delete blob %s: %v", key, err)
+		}
+	}
+}
+
+func randomName() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
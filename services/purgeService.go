@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"petstore-api/repositories"
+	"petstore-api/storage"
+)
+
+// purgeBatchSize caps how many pets are purged per transaction.
+const purgeBatchSize = 500
+
+type purgeService struct {
+	petRepo   repositories.PetRepository
+	userRepo  repositories.UserRepository
+	photoRepo repositories.PhotoRepository
+	store     storage.BlobStore
+}
+
+func NewPurgeService(petRepo repositories.PetRepository, userRepo repositories.UserRepository, photoRepo repositories.PhotoRepository, store storage.BlobStore) PurgeService {
+	return &purgeService{
+		petRepo:   petRepo,
+		userRepo:  userRepo,
+		photoRepo: photoRepo,
+		store:     store,
+	}
+}
+
+// Purge removes pets before users, so that sellers whose last pets go in
+// the same run can be purged too. Pets that were ordered and users with
+// orders are kept for the order history.
+func (s *purgeService) Purge(ctx context.Context, cutoff time.Time) (int, int64, error) {
+	pets := 0
+	for {
+		ids, err := s.petRepo.GetPurgeable(cutoff, purgeBatchSize)
+		if err != nil {
+			return pets, 0, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		photos, err := s.photoRepo.GetByPetIDs(ids)
+		if err != nil {
+			return pets, 0, err
+		}
+		// Photo rows go with the pets through the foreign key; their blobs
+		// are removed once the purge has committed.
+		if err := s.petRepo.Purge(ids); err != nil {
+			return pets, 0, err
+		}
+		for _, photo := range photos {
+			deleteBlobs(ctx, s.store, photo.Key, photo.ThumbnailKey)
+		}
+
+		pets += len(ids)
+		if len(ids) < purgeBatchSize {
+			break
+		}
+	}
+
+	users, err := s.userRepo.Purge(cutoff)
+	if err != nil {
+		return pets, 0, err
+	}
+	return pets, users, nil
+}
+
+// Run purges everything deleted more than retention ago, right away and
+// then every interval, until ctx is done.
+func (s *purgeService) Run(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		cutoff := time.Now().Add(-retention)
+		pets, users, err := s.Purge(ctx, cutoff)
+		if err != nil {
+			log.Printf("failed to purge deleted records: %v", err)
+		} else if pets > 0 || users > 0 {
+			log.Printf("purged %d pets and %d users deleted before %s", pets, users, cutoff.Format(time.RFC3339))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
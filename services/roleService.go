@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"petstore-api/auth"
+	"petstore-api/models"
+	"petstore-api/repositories"
+
+	"gorm.io/gorm"
+)
+
+type roleService struct {
+	userRepo  repositories.UserRepository
+	petRepo   repositories.PetRepository
+	auditRepo repositories.AuditRepository
+}
+
+func NewRoleService(userRepo repositories.UserRepository, petRepo repositories.PetRepository, auditRepo repositories.AuditRepository) RoleService {
+	return &roleService{
+		userRepo:  userRepo,
+		petRepo:   petRepo,
+		auditRepo: auditRepo,
+	}
+}
+
+func (s *roleService) GrantRole(ctx context.Context, userID uint, role models.Role) (*models.User, error) {
+	if !role.Valid() {
+		return nil, errors.New("invalid role")
+	}
+	if err := authorizeRoleChange(ctx, userID, role); err != nil {
+		return nil, err
+	}
+
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.GrantRole(userID, role); err != nil {
+		return nil, err
+	}
+
+	return s.audited(ctx, user)
+}
+
+func (s *roleService) RevokeRole(ctx context.Context, userID uint, role models.Role) (*models.User, error) {
+	if !role.Valid() {
+		return nil, errors.New("invalid role")
+	}
+	if err := authorizeRoleChange(ctx, userID, role); err != nil {
+		return nil, err
+	}
+
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.HasRole(role) {
+		return user, nil
+	}
+	if len(user.Roles) == 1 {
+		return nil, errors.New("cannot revoke the last role of a user")
+	}
+
+	if role == models.RoleSeller {
+		pets, err := s.petRepo.GetBySellerID(userID)
+		if err != nil {
+			return nil, err
+		}
+		if len(pets) > 0 {
+			return nil, errors.New("cannot revoke seller role while the user has pets")
+		}
+	}
+
+	if err := s.userRepo.RevokeRole(userID, role); err != nil {
+		return nil, err
+	}
+
+	return s.audited(ctx, user)
+}
+
+// audited reloads a user after a role change and records the change
+// against before, the user as it was.
+func (s *roleService) audited(ctx context.Context, before *models.User) (*models.User, error) {
+	after, err := s.getUser(before.ID)
+	if err != nil {
+		return nil, err
+	}
+	recordAudit(ctx, s.auditRepo, models.AuditEntry{EntityType: models.AuditEntityUser, EntityID: after.ID, Action: models.AuditActionUpdate}, models.NewUserSnapshot(before), models.NewUserSnapshot(after))
+	return after, nil
+}
+
+// authorizeRoleChange lets users switch their own seller and buyer roles,
+// while the admin role and other users' roles are managed by admins only.
+func authorizeRoleChange(ctx context.Context, userID uint, role models.Role) error {
+	if err := authorizeUser(ctx, userID); err != nil {
+		return err
+	}
+	principal, _ := auth.PrincipalFromContext(ctx)
+	if role == models.RoleAdmin && !principal.IsAdmin() {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func (s *roleService) getUser(userID uint) (*models.User, error) {
+	user, err := s.userRepo.GetByID(userID, false)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	return user, nil
+}
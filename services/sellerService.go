@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 
 	"petstore-api/models"
@@ -10,23 +11,39 @@ import (
 )
 
 type sellerService struct {
+	userAudit
 	sellerRepo repositories.UserRepository
 	petRepo    repositories.PetRepository
 }
 
-func NewSellerService(sellerRepo repositories.UserRepository, petRepo repositories.PetRepository) UserService {
+func NewSellerService(sellerRepo repositories.UserRepository, petRepo repositories.PetRepository, auditRepo repositories.AuditRepository) UserService {
 	return &sellerService{
+		userAudit: userAudit{
+			userRepo:  sellerRepo,
+			auditRepo: auditRepo,
+			role:      models.RoleSeller,
+			notFound:  "seller not found",
+		},
 		sellerRepo: sellerRepo,
 		petRepo:    petRepo,
 	}
 }
 
-func (s *sellerService) GetAll(includePets bool) ([]models.User, error) {
-	return s.sellerRepo.GetAll(includePets)
+func (s *sellerService) GetAll(ctx context.Context, includePets bool, includeDeleted bool, page models.PageRequest) ([]models.User, *models.PageInfo, error) {
+	sellerRepo, err := s.users(ctx, includeDeleted)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sellerRepo.GetAll(includePets, page)
 }
 
-func (s *sellerService) GetByID(id uint, includePets bool) (*models.User, error) {
-	seller, err := s.sellerRepo.GetByID(id, includePets)
+func (s *sellerService) GetByID(ctx context.Context, id uint, includePets bool, includeDeleted bool) (*models.User, error) {
+	sellerRepo, err := s.users(ctx, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+
+	seller, err := sellerRepo.GetByID(id, includePets)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, errors.New("seller not found")
@@ -36,7 +53,7 @@ func (s *sellerService) GetByID(id uint, includePets bool) (*models.User, error)
 	return seller, nil
 }
 
-func (s *sellerService) Create(req *models.CreateUserRequest) (*models.User, error) {
+func (s *sellerService) Create(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
 	if req.Name == "" || req.Email == "" {
 		return nil, errors.New("name and email are required")
 	}
@@ -52,11 +69,16 @@ func (s *sellerService) Create(req *models.CreateUserRequest) (*models.User, err
 	if err != nil {
 		return nil, err
 	}
+	s.recordCreate(ctx, seller)
 
 	return seller, nil
 }
 
-func (s *sellerService) Update(id uint, req *models.UpdateUserRequest) (*models.User, error) {
+func (s *sellerService) Update(ctx context.Context, id uint, req *models.UpdateUserRequest, ifMatch models.VersionMatch) (*models.User, error) {
+	if err := authorizeUser(ctx, id); err != nil {
+		return nil, err
+	}
+
 	seller, err := s.sellerRepo.GetByID(id, false)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -64,6 +86,10 @@ func (s *sellerService) Update(id uint, req *models.UpdateUserRequest) (*models.
 		}
 		return nil, err
 	}
+	if !ifMatch.Matches(seller.Version) {
+		return nil, repositories.ErrVersionConflict
+	}
+	before := models.NewUserSnapshot(seller)
 
 	if req.Name != "" {
 		seller.Name = req.Name
@@ -82,12 +108,17 @@ func (s *sellerService) Update(id uint, req *models.UpdateUserRequest) (*models.
 	if err != nil {
 		return nil, err
 	}
+	s.recordUpdate(ctx, before, seller)
 
 	return seller, nil
 }
 
-func (s *sellerService) Delete(id uint) error {
-	_, err := s.sellerRepo.GetByID(id, false)
+func (s *sellerService) Delete(ctx context.Context, id uint) error {
+	if err := authorizeUser(ctx, id); err != nil {
+		return err
+	}
+
+	seller, err := s.sellerRepo.GetByID(id, false)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return errors.New("seller not found")
@@ -95,5 +126,17 @@ func (s *sellerService) Delete(id uint) error {
 		return err
 	}
 
-	return s.sellerRepo.Delete(id)
+	pets, err := s.petRepo.GetBySellerID(id)
+	if err != nil {
+		return err
+	}
+	if len(pets) > 0 {
+		return errors.New("cannot delete seller with existing pets")
+	}
+
+	if err := s.sellerRepo.Delete(id); err != nil {
+		return err
+	}
+	s.recordDelete(ctx, seller)
+	return nil
 }
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"errors"
+
+	"petstore-api/models"
+	"petstore-api/repositories"
+
+	"gorm.io/gorm"
+)
+
+type taxonomyService struct {
+	taxonomyRepo repositories.TaxonomyRepository
+}
+
+func NewTaxonomyService(taxonomyRepo repositories.TaxonomyRepository) TaxonomyService {
+	return &taxonomyService{taxonomyRepo: taxonomyRepo}
+}
+
+func (s *taxonomyService) GetAllSpecies() ([]models.Species, error) {
+	return s.taxonomyRepo.GetAllSpecies()
+}
+
+func (s *taxonomyService) GetSpecies(id uint) (*models.Species, error) {
+	species, err := s.taxonomyRepo.GetSpeciesByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("species not found")
+		}
+		return nil, err
+	}
+	return species, nil
+}
+
+func (s *taxonomyService) CreateSpecies(req *models.TaxonomyRequest) (*models.Species, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkSpeciesNames(0, req); err != nil {
+		return nil, err
+	}
+
+	species := &models.Species{Name: req.Name, Aliases: speciesAliases(0, req.Aliases)}
+	if err := s.taxonomyRepo.CreateSpecies(species); err != nil {
+		return nil, err
+	}
+
+	return species, nil
+}
+
+// UpdateSpecies renames a species and replaces its aliases. Pets listed
+// under the old name are renamed with it.
+func (s *taxonomyService) UpdateSpecies(id uint, req *models.TaxonomyRequest) (*models.Species, error) {
+	species, err := s.GetSpecies(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkSpeciesNames(id, req); err != nil {
+		return nil, err
+	}
+
+	previousName := species.Name
+	species.Name = req.Name
+	species.Aliases = speciesAliases(id, req.Aliases)
+	if err := s.taxonomyRepo.UpdateSpecies(species, previousName); err != nil {
+		return nil, err
+	}
+
+	return species, nil
+}
+
+func (s *taxonomyService) DeleteSpecies(id uint) error {
+	if _, err := s.GetSpecies(id); err != nil {
+		return err
+	}
+	if err := s.taxonomyRepo.DeleteSpecies(id); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("species not found")
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *taxonomyService) GetBreeds(speciesID uint) ([]models.Breed, error) {
+	species, err := s.GetSpecies(speciesID)
+	if err != nil {
+		return nil, err
+	}
+	return species.Breeds, nil
+}
+
+func (s *taxonomyService) CreateBreed(speciesID uint, req *models.TaxonomyRequest) (*models.Breed, error) {
+	if _, err := s.GetSpecies(speciesID); err != nil {
+		return nil, err
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkBreedNames(speciesID, 0, req); err != nil {
+		return nil, err
+	}
+
+	breed := &models.Breed{SpeciesID: speciesID, Name: req.Name, Aliases: breedAliases(speciesID, 0, req.Aliases)}
+	if err := s.taxonomyRepo.CreateBreed(breed); err != nil {
+		return nil, err
+	}
+
+	return breed, nil
+}
+
+// UpdateBreed renames a breed and replaces its aliases. Pets of the species
+// listed under the old name are renamed with it.
+func (s *taxonomyService) UpdateBreed(id uint, req *models.TaxonomyRequest) (*models.Breed, error) {
+	breed, err := s.getBreed(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkBreedNames(breed.SpeciesID, id, req); err != nil {
+		return nil, err
+	}
+
+	previousName := breed.Name
+	breed.Name = req.Name
+	breed.Aliases = breedAliases(breed.SpeciesID, id, req.Aliases)
+	if err := s.taxonomyRepo.UpdateBreed(breed, previousName); err != nil {
+		return nil, err
+	}
+
+	return breed, nil
+}
+
+func (s *taxonomyService) DeleteBreed(id uint) error {
+	if _, err := s.getBreed(id); err != nil {
+		return err
+	}
+	return s.taxonomyRepo.DeleteBreed(id)
+}
+
+func (s *taxonomyService) getBreed(id uint) (*models.Breed, error) {
+	breed, err := s.taxonomyRepo.GetBreedByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("breed not found")
+		}
+		return nil, err
+	}
+	return breed, nil
+}
+
+// checkSpeciesNames makes sure the name and aliases do not already stand for
+// a different species, so every lookup has exactly one answer.
+func (s *taxonomyService) checkSpeciesNames(id uint, req *models.TaxonomyRequest) error {
+	var verr models.ValidationError
+	for _, name := range append([]string{req.Name}, req.Aliases...) {
+		other, err := s.taxonomyRepo.FindSpecies(name)
+		if err == gorm.ErrRecordNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if other.ID != id {
+			verr.Add(taxonomyField(name, req), "\""+name+"\" already refers to species "+other.Name)
+		}
+	}
+	return verr.Err()
+}
+
+func (s *taxonomyService) checkBreedNames(speciesID uint, id uint, req *models.TaxonomyRequest) error {
+	var verr models.ValidationError
+	for _, name := range append([]string{req.Name}, req.Aliases...) {
+		other, err := s.taxonomyRepo.FindBreed(speciesID, name)
+		if err == gorm.ErrRecordNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if other.ID != id {
+			verr.Add(taxonomyField(name, req), "\""+name+"\" already refers to breed "+other.Name)
+		}
+	}
+	return verr.Err()
+}
+
+func taxonomyField(name string, req *models.TaxonomyRequest) string {
+	if name == req.Name {
+		return "name"
+	}
+	return "aliases"
+}
+
+func speciesAliases(speciesID uint, names []string) []models.SpeciesAlias {
+	aliases := make([]models.SpeciesAlias, len(names))
+	for i, name := range names {
+		aliases[i] = models.SpeciesAlias{SpeciesID: speciesID, Name: name}
+	}
+	return aliases
+}
+
+func breedAliases(speciesID uint, breedID uint, names []string) []models.BreedAlias {
+	aliases := make([]models.BreedAlias, len(names))
+	for i, name := range names {
+		aliases[i] = models.BreedAlias{BreedID: breedID, SpeciesID: speciesID, Name: name}
+	}
+	return aliases
+}
+
+// normalizeTaxonomy maps a species and breed, or their aliases, to their
+// canonical names. Unknown species, and breeds unknown for the species, are
+// rejected. An empty breed is allowed for mixed or unknown breeds.
+func normalizeTaxonomy(taxonomyRepo repositories.TaxonomyRepository, speciesName, breedName string) (string, string, error) {
+	var verr models.ValidationError
+
+	species, err := taxonomyRepo.FindSpecies(speciesName)
+	if err == gorm.ErrRecordNotFound {
+		verr.Add("species", "unknown species \""+speciesName+"\"")
+		return "", "", verr.Err()
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if breedName == "" {
+		return species.Name, "", nil
+	}
+
+	breed, err := taxonomyRepo.FindBreed(species.ID, breedName)
+	if err == gorm.ErrRecordNotFound {
+		verr.Add("breed", "unknown breed \""+breedName+"\" for species "+species.Name)
+		return "", "", verr.Err()
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return species.Name, breed.Name, nil
+}